@@ -0,0 +1,220 @@
+package apilog
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy define what AsyncWriter should do when the queue is full.
+type OverflowPolicy int8
+
+const (
+	// BlockOnFull block the caller until there is room in the queue.
+	BlockOnFull OverflowPolicy = iota
+	// DropNewest discard the incoming log when the queue is full.
+	DropNewest
+	// DropOldest discard the oldest queued log to make room for the incoming
+	// one when the queue is full.
+	DropOldest
+)
+
+// AsyncOpt options for AsyncWriter.
+type AsyncOpt func(*AsyncWriter)
+
+// WithAsyncQueueSize set maximum number of logs that can be queued before the
+// OverflowPolicy kicks in. Default to 1024.
+func WithAsyncQueueSize(n int) AsyncOpt {
+	return func(a *AsyncWriter) {
+		if n > 0 {
+			a.size = n
+		}
+	}
+}
+
+// WithAsyncOverflow set the OverflowPolicy used when the queue is full.
+// Default to BlockOnFull.
+func WithAsyncOverflow(p OverflowPolicy) AsyncOpt {
+	return func(a *AsyncWriter) {
+		a.policy = p
+	}
+}
+
+// NewAsyncWriter return Writer implementer that wraps given w with a bounded
+// in-memory queue and a background goroutine, so the caller never has to wait
+// for a slow w to finish writing the logs.
+func NewAsyncWriter(w Writer, opts ...AsyncOpt) *AsyncWriter {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &AsyncWriter{
+		wr:     w,
+		size:   1024,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		quit:   make(chan struct{}),
+	}
+	// apply options
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.queue = make(chan []byte, a.size)
+
+	go a.run()
+	return a
+}
+
+// AsyncWriter wraps a Writer and write the logs to it asynchronously.
+type AsyncWriter struct {
+	wr     Writer
+	size   int
+	policy OverflowPolicy
+	queue  chan []byte
+
+	mu      sync.RWMutex // guard closed against in-flight Write
+	closed  bool
+	pending sync.WaitGroup // logs that are not queued yet
+	once    sync.Once
+	ctx     context.Context // canceled once the deadline of Flush is passed
+	cancel  context.CancelFunc
+	quit    chan struct{}
+	done    chan struct{}
+	wmu     sync.Mutex // serialise writes to the wrapped Writer
+
+	dropped atomic.Uint64
+}
+
+// Write implement io.Writer by queueing a copy of p to be written later by the
+// background goroutine.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	// already flushed, so just write it directly
+	if a.closed {
+		a.mu.RUnlock()
+		return a.write(p)
+	}
+	a.pending.Add(1)
+	a.mu.RUnlock()
+	defer a.pending.Done()
+
+	// zap and slog reuse their buffer after Write returns
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	switch a.policy {
+	case DropNewest:
+		select {
+		case a.queue <- b:
+		default:
+			a.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- b:
+				return len(p), nil
+			default:
+			}
+			// make room by discarding the oldest one
+			select {
+			case <-a.queue:
+				a.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		// never block longer than the deadline of Flush
+		select {
+		case a.queue <- b:
+		case <-a.ctx.Done():
+			a.dropped.Add(1)
+		}
+	}
+	return len(p), nil
+}
+
+func (a *AsyncWriter) Writer() io.Writer      { return a }
+func (a *AsyncWriter) Output() Output         { return a.wr.Output() }
+func (a *AsyncWriter) Level() Level           { return a.wr.Level() }
+func (a *AsyncWriter) Wait(dur time.Duration) { a.wr.Wait(dur) }
+
+// AtomicLevel return the AtomicLevel of the wrapped Writer if any.
+func (a *AsyncWriter) AtomicLevel() *AtomicLevel { return atomicLevelOf(a.wr) }
+
+// Flush drain the queue within given dur then delegate the rest of the
+// remaining dur to the wrapped Writer's Flush.
+func (a *AsyncWriter) Flush(dur time.Duration) {
+	deadline := time.Now().Add(dur)
+	t := time.AfterFunc(dur, a.cancel)
+	defer t.Stop()
+	a.once.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		a.mu.Unlock()
+		close(a.quit)
+	})
+
+	select {
+	case <-a.done:
+	case <-a.ctx.Done():
+	}
+	a.wr.Flush(time.Until(deadline))
+}
+
+// Unwrap return the wrapped Writer.
+func (a *AsyncWriter) Unwrap() Writer { return a.wr }
+
+// Dropped return the number of logs discarded because the queue was full.
+func (a *AsyncWriter) Dropped() uint64 { return a.dropped.Load() }
+
+// Pending return the number of logs that are still waiting in the queue.
+func (a *AsyncWriter) Pending() int { return len(a.queue) }
+
+// write write given p to the wrapped Writer, so the logs written after Flush
+// never interleave with the ones still being drained.
+func (a *AsyncWriter) write(p []byte) (int, error) {
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+	return a.wr.Writer().Write(p)
+}
+
+// run write every queued log to the wrapped Writer until Flush is called, then
+// drain whatever left in the queue.
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	for {
+		select {
+		case b := <-a.queue:
+			_, _ = a.write(b)
+		case <-a.quit:
+			a.drain()
+			return
+		}
+	}
+}
+
+// drain write the queued logs until every in-flight Write has queued its log,
+// then write whatever left.
+func (a *AsyncWriter) drain() {
+	queued := make(chan struct{})
+	go func() {
+		a.pending.Wait()
+		close(queued)
+	}()
+	for {
+		select {
+		case b := <-a.queue:
+			_, _ = a.write(b)
+		case <-queued:
+			for {
+				select {
+				case b := <-a.queue:
+					_, _ = a.write(b)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package apilog
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockedWriter Writer implementer that hold every Write until release is
+// closed.
+type blockedWriter struct {
+	*ObservedLog
+	release chan struct{}
+	flushed bool
+}
+
+func (b *blockedWriter) Writer() io.Writer { return b }
+
+func (b *blockedWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.ObservedLog.Write(p)
+}
+
+func (b *blockedWriter) Flush(_ time.Duration) { b.flushed = true }
+
+func newBlockedWriter() *blockedWriter {
+	_, obs := NewObserverWriter(DebugLevel, FILE)
+	return &blockedWriter{ObservedLog: obs, release: make(chan struct{})}
+}
+
+func TestNewAsyncWriter(t *testing.T) {
+	t.Run("Should delegate to the wrapped Writer", func(t *testing.T) {
+		wr, _ := NewObserverWriter(WarnLevel, FILE)
+		aw := NewAsyncWriter(wr)
+		assert.Equal(t, aw, aw.Writer())
+		assert.Equal(t, FILE, aw.Output())
+		assert.Equal(t, WarnLevel, aw.Level())
+
+		// just run
+		aw.Wait(-1)
+		aw.Flush(time.Second)
+	})
+
+	t.Run("Should write all the logs after flushed", func(t *testing.T) {
+		wr, obs := NewObserverWriter(DebugLevel, FILE)
+		lg := NewZapLogger(NewAsyncWriter(wr))
+		lg.Init(time.Microsecond)
+
+		lg.Inf("first")
+		lg.Inf("second", String("hello", "world"))
+		lg.Flush(time.Second)
+
+		require.Equal(t, 2, obs.Len())
+		assert.True(t, obs.All()[0].EqualMsg("first"))
+		assert.Equal(t, "world", obs.All()[1].Get("hello"))
+	})
+
+	t.Run("Should write directly after flushed", func(t *testing.T) {
+		wr, obs := NewObserverWriter(DebugLevel, FILE)
+		aw := NewAsyncWriter(wr)
+		aw.Flush(time.Second)
+
+		_, _ = aw.Write([]byte(`{"msg":"late"}`))
+		require.Equal(t, 1, obs.Len())
+		assert.True(t, obs.All()[0].EqualMsg("late"))
+	})
+
+	t.Run("Drop newest should discard the incoming logs", func(t *testing.T) {
+		bw := newBlockedWriter()
+		aw := NewAsyncWriter(bw, WithAsyncQueueSize(2), WithAsyncOverflow(DropNewest))
+
+		for _, msg := range []string{"1", "2", "3", "4", "5"} {
+			_, _ = aw.Write([]byte(`{"msg":"` + msg + `"}`))
+		}
+		// the first one may already be taken by the background goroutine
+		assert.GreaterOrEqual(t, aw.Dropped(), uint64(2))
+
+		close(bw.release)
+		aw.Flush(time.Second)
+		assert.True(t, bw.flushed)
+		assert.Equal(t, 0, aw.Pending())
+		assert.Equal(t, 5, bw.Len()+int(aw.Dropped()))
+		assert.True(t, bw.All()[0].EqualMsg("1"))
+	})
+
+	t.Run("Drop oldest should keep the latest logs", func(t *testing.T) {
+		bw := newBlockedWriter()
+		aw := NewAsyncWriter(bw, WithAsyncQueueSize(2), WithAsyncOverflow(DropOldest))
+
+		for _, msg := range []string{"1", "2", "3", "4", "5"} {
+			_, _ = aw.Write([]byte(`{"msg":"` + msg + `"}`))
+		}
+		assert.GreaterOrEqual(t, aw.Dropped(), uint64(2))
+
+		close(bw.release)
+		aw.Flush(time.Second)
+		logs := bw.All()
+		assert.Equal(t, 5, len(logs)+int(aw.Dropped()))
+		assert.True(t, logs[len(logs)-1].EqualMsg("5"))
+	})
+
+	t.Run("Flush should respect the deadline", func(t *testing.T) {
+		bw := newBlockedWriter()
+		aw := NewAsyncWriter(bw)
+		_, _ = aw.Write([]byte(`{"msg":"stuck"}`))
+
+		start := time.Now()
+		aw.Flush(50 * time.Millisecond)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.True(t, bw.flushed)
+		close(bw.release)
+	})
+	t.Run("Given blocked Write should not block Flush longer than the deadline", func(t *testing.T) {
+		bw := newBlockedWriter()
+		aw := NewAsyncWriter(bw, WithAsyncQueueSize(1))
+		// one being written and the other fill the queue
+		_, _ = aw.Write([]byte(`{"msg":"1"}`))
+		_, _ = aw.Write([]byte(`{"msg":"2"}`))
+		written := make(chan struct{})
+		go func() {
+			_, _ = aw.Write([]byte(`{"msg":"3"}`))
+			close(written)
+		}()
+		// make sure it is blocked on the full queue before flushing
+		time.Sleep(20 * time.Millisecond)
+
+		start := time.Now()
+		aw.Flush(50 * time.Millisecond)
+		assert.Less(t, time.Since(start), time.Second)
+		<-written
+		assert.Equal(t, uint64(1), aw.Dropped())
+		close(bw.release)
+	})
+
+	t.Run("Given Flush timed out should not write concurrently with the remaining logs", func(t *testing.T) {
+		bw := newBlockedWriter()
+		aw := NewAsyncWriter(bw)
+		_, _ = aw.Write([]byte(`{"msg":"stuck"}`))
+		aw.Flush(50 * time.Millisecond)
+
+		written := make(chan struct{})
+		go func() {
+			_, _ = aw.Write([]byte(`{"msg":"late"}`))
+			close(written)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(bw.release)
+		<-written
+
+		logs := bw.All()
+		require.Len(t, logs, 2)
+		assert.True(t, logs[0].EqualMsg("stuck"))
+		assert.True(t, logs[1].EqualMsg("late"))
+	})
+}
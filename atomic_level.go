@@ -0,0 +1,39 @@
+package apilog
+
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// NewAtomicLevel return new AtomicLevel that set to given lvl.
+func NewAtomicLevel(lvl Level) *AtomicLevel {
+	a := &AtomicLevel{
+		zap:  zap.NewAtomicLevel(),
+		slog: new(slog.LevelVar),
+	}
+	a.SetLevel(lvl)
+	return a
+}
+
+// AtomicLevel is a log Level that can be safely changed at runtime while the
+// Logger is still writing logs. Each of the backend will be notified as well,
+// so the change take effect immediately without the need to call Init again.
+type AtomicLevel struct {
+	lvl  atomic.Int32
+	zap  zap.AtomicLevel
+	slog *slog.LevelVar
+}
+
+// Level return the current Level.
+func (a *AtomicLevel) Level() Level {
+	return Level(a.lvl.Load())
+}
+
+// SetLevel change the current Level to given lvl.
+func (a *AtomicLevel) SetLevel(lvl Level) {
+	a.lvl.Store(int32(lvl))
+	a.zap.SetLevel(toZapLevel(lvl))
+	a.slog.Set(toSlogLevel(lvl))
+}
@@ -0,0 +1,48 @@
+package apilog
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAtomicLevel(t *testing.T) {
+	t.Run("Should keep every backend in sync", func(t *testing.T) {
+		al := NewAtomicLevel(InfoLevel)
+		assert.Equal(t, InfoLevel, al.Level())
+		assert.Equal(t, zapcore.InfoLevel, al.zap.Level())
+		assert.Equal(t, slog.LevelInfo, al.slog.Level())
+
+		al.SetLevel(ErrorLevel)
+		assert.Equal(t, ErrorLevel, al.Level())
+		assert.Equal(t, zapcore.ErrorLevel, al.zap.Level())
+		assert.Equal(t, slog.LevelError, al.slog.Level())
+	})
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" logger should follow the changed Level without calling Init again", func(t *testing.T) {
+			writer, obs := NewObserverWriter(ErrorLevel, FILE)
+			wr := tc.fn(writer)
+			wr.Init(time.Microsecond)
+
+			wr.Dbg("not written")
+			require.Equal(t, 0, obs.Len())
+
+			writer.(LevelAdjuster).AtomicLevel().SetLevel(DebugLevel)
+			wr.Dbg("written")
+			require.Equal(t, 1, obs.Len())
+			assert.True(t, obs.All()[0].EqualMsg("written"))
+		})
+	}
+}
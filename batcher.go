@@ -0,0 +1,190 @@
+package apilog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// batchQueueSize maximum number of batches waiting to be sent before Write
+	// starts blocking.
+	batchQueueSize = 16
+	// closedSendTimeout maximum time to send a log written after Flush.
+	closedSendTimeout = 5 * time.Second
+)
+
+// newBatcher return batcher that send each batch using given send when it
+// reach given count or maxBytes, or every given interval, whichever comes first.
+// Logs that failed to be sent are reported to given onError if any.
+func newBatcher(count, maxBytes int, interval time.Duration, send func(context.Context, [][]byte) error, onError func(error)) *batcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &batcher{
+		count:    count,
+		bytes:    maxBytes,
+		interval: interval,
+		send:     send,
+		onError:  onError,
+		queue:    make(chan [][]byte, batchQueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// batcher batch the logs then send each batch in the background. Shared by
+// every Writer that send logs in batches.
+type batcher struct {
+	count    int
+	bytes    int
+	interval time.Duration
+	send     func(context.Context, [][]byte) error
+	onError  func(error)
+	queue    chan [][]byte
+
+	mu    sync.Mutex // guard batch and size
+	batch [][]byte
+	size  int
+
+	cmu     sync.RWMutex // guard closed against in-flight Write
+	closed  bool
+	pending sync.WaitGroup // full batches that are not queued yet
+	once    sync.Once
+	ctx     context.Context
+	cancel  context.CancelFunc
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// Write implement io.Writer by adding a copy of p to the current batch.
+func (b *batcher) Write(p []byte) (int, error) {
+	// zap and slog reuse their buffer after Write returns
+	e := bytes.Clone(bytes.TrimSpace(p))
+	if len(e) == 0 {
+		return len(p), nil
+	}
+
+	b.cmu.RLock()
+	// already flushed, so just send it directly
+	if b.closed {
+		b.cmu.RUnlock()
+		ctx, cancel := context.WithTimeout(context.Background(), closedSendTimeout)
+		defer cancel()
+		if err := b.send(ctx, [][]byte{e}); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	b.mu.Lock()
+	b.batch = append(b.batch, e)
+	b.size += len(e)
+	var full [][]byte
+	if len(b.batch) >= b.count || b.size >= b.bytes {
+		full = b.cut()
+		b.pending.Add(1)
+	}
+	b.mu.Unlock()
+	b.cmu.RUnlock()
+
+	if full == nil {
+		return len(p), nil
+	}
+	defer b.pending.Done()
+	// never block longer than the deadline of Flush
+	select {
+	case b.queue <- full:
+		return len(p), nil
+	case <-b.ctx.Done():
+		return 0, b.fail(len(full), b.ctx.Err())
+	}
+}
+
+// fail report that given number of logs failed to be sent to the error
+// handler if any, then return the reported error.
+func (b *batcher) fail(n int, err error) error {
+	err = fmt.Errorf("failed to send %d logs: %w", n, err)
+	if b.onError != nil {
+		b.onError(err)
+	}
+	return err
+}
+
+// cut return the current batch and start a new one.
+func (b *batcher) cut() [][]byte {
+	e := b.batch
+	b.batch, b.size = nil, 0
+	return e
+}
+
+// sendCurrent send the current batch if not empty.
+func (b *batcher) sendCurrent() {
+	b.mu.Lock()
+	e := b.cut()
+	b.mu.Unlock()
+	if len(e) > 0 {
+		_ = b.send(b.ctx, e)
+	}
+}
+
+// run send every full batch and the current batch every interval until Flush
+// is called, then send whatever left.
+func (b *batcher) run() {
+	defer close(b.done)
+	tick := time.NewTicker(b.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case e := <-b.queue:
+			_ = b.send(b.ctx, e)
+		case <-tick.C:
+			b.sendCurrent()
+		case <-b.quit:
+			b.drain()
+			return
+		}
+	}
+}
+
+// drain send the queued batches until every in-flight Write has queued its
+// full batch, then send whatever left.
+func (b *batcher) drain() {
+	queued := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(queued)
+	}()
+	for {
+		select {
+		case e := <-b.queue:
+			_ = b.send(b.ctx, e)
+		case <-queued:
+			// nothing is queued anymore once closed
+			for len(b.queue) > 0 {
+				_ = b.send(b.ctx, <-b.queue)
+			}
+			b.sendCurrent()
+			return
+		}
+	}
+}
+
+// Flush send the remaining batch within given dur. The context passed to
+// send is canceled once dur is passed.
+func (b *batcher) Flush(dur time.Duration) {
+	t := time.AfterFunc(dur, b.cancel)
+	defer t.Stop()
+	b.once.Do(func() {
+		b.cmu.Lock()
+		b.closed = true
+		b.cmu.Unlock()
+		close(b.quit)
+	})
+	<-b.done
+}
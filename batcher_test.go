@@ -0,0 +1,118 @@
+package apilog
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordBatches return send func that record each batch.
+func recordBatches() (func(context.Context, [][]byte) error, func() [][][]byte) {
+	var mu sync.Mutex
+	var batches [][][]byte
+	send := func(_ context.Context, e [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, e)
+		return nil
+	}
+	return send, func() [][][]byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	t.Run("Should send the batch once it reach the maximum number of logs", func(t *testing.T) {
+		send, got := recordBatches()
+		b := newBatcher(2, 1<<20, time.Hour, send, nil)
+		for _, s := range []string{"a", "b", "c"} {
+			_, _ = b.Write([]byte(s + "\n"))
+		}
+		assert.Eventually(t, func() bool { return len(got()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, got()[0])
+
+		b.Flush(time.Second)
+		assert.Equal(t, [][]byte{[]byte("c")}, got()[1])
+	})
+
+	t.Run("Should send the batch once it reach the maximum number of bytes", func(t *testing.T) {
+		send, got := recordBatches()
+		b := newBatcher(100, 4, time.Hour, send, nil)
+		_, _ = b.Write([]byte("abcd"))
+		assert.Eventually(t, func() bool { return len(got()) == 1 }, time.Second, time.Millisecond)
+		b.Flush(time.Second)
+	})
+
+	t.Run("Should send the current batch every interval", func(t *testing.T) {
+		send, got := recordBatches()
+		b := newBatcher(100, 1<<20, 10*time.Millisecond, send, nil)
+		_, _ = b.Write([]byte("a"))
+		assert.Eventually(t, func() bool { return len(got()) == 1 }, time.Second, time.Millisecond)
+		b.Flush(time.Second)
+	})
+
+	t.Run("Given empty log should ignore it", func(t *testing.T) {
+		send, got := recordBatches()
+		b := newBatcher(1, 1<<20, time.Hour, send, nil)
+		n, err := b.Write([]byte("\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		b.Flush(time.Second)
+		assert.Empty(t, got())
+	})
+
+	t.Run("Given already flushed should send each log directly", func(t *testing.T) {
+		send, got := recordBatches()
+		b := newBatcher(100, 1<<20, time.Hour, send, nil)
+		b.Flush(time.Second)
+		b.Flush(time.Second) // flush more than once is fine
+		_, _ = b.Write([]byte("a"))
+		assert.Equal(t, [][][]byte{{[]byte("a")}}, got())
+	})
+
+	t.Run("Given already flushed should send each log with bounded context", func(t *testing.T) {
+		var ok bool
+		b := newBatcher(100, 1<<20, time.Hour, func(ctx context.Context, _ [][]byte) error {
+			_, ok = ctx.Deadline()
+			return nil
+		}, nil)
+		b.Flush(time.Second)
+		_, _ = b.Write([]byte("a"))
+		assert.True(t, ok)
+	})
+
+	t.Run("Given blocked Write should not block Flush longer than given duration", func(t *testing.T) {
+		b := newBatcher(1, 1<<20, time.Hour, func(ctx context.Context, _ [][]byte) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, nil)
+		// one being sent and the rest fill the queue
+		for i := 0; i <= batchQueueSize; i++ {
+			_, _ = b.Write([]byte("a"))
+		}
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := b.Write([]byte("blocked"))
+			errCh <- err
+		}()
+		// make sure it is blocked on the full queue before flushing
+		time.Sleep(20 * time.Millisecond)
+
+		flushed := make(chan struct{})
+		go func() {
+			b.Flush(50 * time.Millisecond)
+			close(flushed)
+		}()
+		select {
+		case <-flushed:
+		case <-time.After(time.Second):
+			t.Fatal("Flush is blocked by Write")
+		}
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	})
+}
@@ -1,5 +1,13 @@
 package apilog
 
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
 // NewConfig return new Config after applying given options.
 func NewConfig(opts ...ConfigOpt) *Config {
 	var c Config
@@ -13,8 +21,21 @@ func NewConfig(opts ...ConfigOpt) *Config {
 type (
 	// Config required object that holds any necessary data used by each log output implementation
 	Config struct {
-		nr   NRConfig
-		file FileConfig
+		nr       NRConfig
+		file     FileConfig
+		redact   RedactConfig
+		syslog   SyslogConfig
+		http     HTTPConfig
+		loki     LokiConfig
+		otlp     OTLPConfig
+		es       ElasticsearchConfig
+		fluent   FluentConfig
+		gelf     GELFConfig
+		splunk   SplunkConfig
+		datadog  DatadogConfig
+		kafka    KafkaConfig
+		journald JournaldConfig
+		network  NetworkConfig
 	}
 	// NRConfig specific config for new relic as the log output
 	NRConfig struct {
@@ -23,10 +44,141 @@ type (
 	}
 	// FileConfig specific config for file as the log output
 	FileConfig struct {
-		path string
-		size int
-		age  int
-		num  int
+		path     string
+		size     int
+		age      int
+		num      int
+		every    time.Duration
+		pattern  string
+		compress Compression
+		fileMode os.FileMode
+		dirMode  os.FileMode
+		chown    bool
+		uid      int
+		gid      int
+	}
+	// SyslogConfig specific config for syslog as the log output
+	SyslogConfig struct {
+		network  string
+		addr     string
+		facility SyslogFacility
+		app      string
+		host     string
+		framing  SyslogFraming
+		tls      *tls.Config
+	}
+	// HTTPConfig specific config for HTTP endpoint as the log output
+	HTTPConfig struct {
+		url         string
+		method      string
+		contentType string
+		header      http.Header
+		count       int
+		bytes       int
+		interval    time.Duration
+		noGzip      bool
+		retry       int
+		backoff     time.Duration
+		client      *http.Client
+		onError     func(error)
+	}
+	// LokiConfig specific config for grafana loki as the log output
+	LokiConfig struct {
+		url      string
+		tenant   string
+		labels   []string
+		static   map[string]string
+		protobuf bool
+	}
+	// OTLPConfig specific config for OpenTelemetry collector as the log output
+	OTLPConfig struct {
+		endpoint string
+		service  string
+		resource map[string]string
+	}
+	// ElasticsearchConfig specific config for elasticsearch or opensearch as
+	// the log output
+	ElasticsearchConfig struct {
+		url    string
+		index  string
+		user   string
+		pass   string
+		apiKey string
+	}
+	// FluentConfig specific config for fluentd or fluent bit as the log output
+	FluentConfig struct {
+		network  string
+		addr     string
+		tag      string
+		ack      bool
+		count    int
+		interval time.Duration
+		retry    int
+		backoff  time.Duration
+		onError  func(error)
+	}
+	// GELFConfig specific config for graylog as the log output
+	GELFConfig struct {
+		network  string
+		addr     string
+		host     string
+		compress GELFCompression
+		chunk    int
+	}
+	// SplunkConfig specific config for splunk HTTP Event Collector as the log
+	// output
+	SplunkConfig struct {
+		url        string
+		token      string
+		host       string
+		source     string
+		sourcetype string
+		index      string
+		fields     []string
+		static     map[string]string
+		ack        time.Duration
+	}
+	// DatadogConfig specific config for datadog as the log output
+	DatadogConfig struct {
+		url     string
+		apiKey  string
+		service string
+		source  string
+		tags    []string
+		host    string
+	}
+	// KafkaConfig specific config for kafka as the log output
+	KafkaConfig struct {
+		producer KafkaProducer
+		topic    string
+		key      string
+		count    int
+		bytes    int
+		linger   time.Duration
+		onError  func(error)
+	}
+	// JournaldConfig specific config for systemd journald as the log output
+	JournaldConfig struct {
+		socket     string
+		identifier string
+	}
+	// NetworkConfig specific config for tcp, udp or unix socket as the log
+	// output
+	NetworkConfig struct {
+		network string
+		addr    string
+		tls     *tls.Config
+		buffer  int
+		backoff time.Duration
+		onError func(error)
+	}
+	// RedactConfig specific config for redacting sensitive Log(s), used by
+	// WithRedaction
+	RedactConfig struct {
+		keys    []string
+		keyRe   []*regexp.Regexp
+		valueRe []*regexp.Regexp
+		masker  Masker
 	}
 )
 
@@ -77,3 +229,675 @@ func WithFileMaxBackup(max int) ConfigOpt {
 		c.file.num = max
 	}
 }
+
+// WithFileRotation set interval to rotate the log file by time in addition to
+// size e.g. time.Hour for hourly or 24 * time.Hour for daily rotation. The
+// interval is aligned to the local time, so daily rotation happen at local
+// midnight.
+func WithFileRotation(every time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.file.every = every
+	}
+}
+
+// WithFilePattern set file name pattern used when rotating by time e.g.
+// './logs/app-%Y-%m-%d.log'. Supported verbs are %Y, %m, %d, %H, %M and %%.
+// Default to file path suffixed by the date and time that fit the rotation
+// interval.
+func WithFilePattern(pattern string) ConfigOpt {
+	return func(c *Config) {
+		c.file.pattern = pattern
+	}
+}
+
+// WithFileCompress set how the rotated log files should be compressed. Default
+// to NoCompression.
+func WithFileCompress(c Compression) ConfigOpt {
+	return func(cnf *Config) {
+		cnf.file.compress = c
+	}
+}
+
+// WithFileMode set permissions of both the active and rotated log files e.g.
+// 0640. Default to lumberjack permissions which is 0600 for new files.
+func WithFileMode(mode os.FileMode) ConfigOpt {
+	return func(c *Config) {
+		c.file.fileMode = mode
+	}
+}
+
+// WithDirMode set permissions of the log directory if it does not exist yet
+// e.g. 0750. Default to lumberjack permissions which is 0755.
+func WithDirMode(mode os.FileMode) ConfigOpt {
+	return func(c *Config) {
+		c.file.dirMode = mode
+	}
+}
+
+// WithFileOwner set owner and group of both the active and rotated log files.
+// Changing the owner usually require root privileges.
+func WithFileOwner(uid, gid int) ConfigOpt {
+	return func(c *Config) {
+		c.file.chown = true
+		c.file.uid = uid
+		c.file.gid = gid
+	}
+}
+
+// WithRedactKeys set case-insensitive key names whose value should be masked
+// e.g. password, token.
+func WithRedactKeys(keys ...string) ConfigOpt {
+	return func(c *Config) {
+		c.redact.keys = append(c.redact.keys, keys...)
+	}
+}
+
+// WithRedactKeyPattern set regex that match key names whose value should be
+// masked.
+func WithRedactKeyPattern(re *regexp.Regexp) ConfigOpt {
+	return func(c *Config) {
+		c.redact.keyRe = append(c.redact.keyRe, re)
+	}
+}
+
+// WithRedactValuePattern set regex that match any part of string values that
+// should be masked regardless of the key e.g. email address.
+func WithRedactValuePattern(re *regexp.Regexp) ConfigOpt {
+	return func(c *Config) {
+		c.redact.valueRe = append(c.redact.valueRe, re)
+	}
+}
+
+// WithRedactMasker set how the sensitive value should be masked. Default to
+// MaskFull.
+func WithRedactMasker(m Masker) ConfigOpt {
+	return func(c *Config) {
+		c.redact.masker = m
+	}
+}
+
+// WithSyslogNetwork set network used to connect to the syslog collector. One
+// of udp, tcp, tls, unix or unixgram. Default to udp.
+func WithSyslogNetwork(network string) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.network = network
+	}
+}
+
+// WithSyslogAddress set address of the syslog collector e.g. 'localhost:514'
+// or '/dev/log' for unix socket. Default to 'localhost:514'.
+func WithSyslogAddress(addr string) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.addr = addr
+	}
+}
+
+// WithSyslogFacility set syslog facility the logs are sent as. Default to
+// SyslogUser. SyslogKern is reserved for the kernel, so it's treated as
+// SyslogUser the same way glibc syslog does.
+func WithSyslogFacility(f SyslogFacility) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.facility = f
+	}
+}
+
+// WithSyslogAppName set APP-NAME of each syslog message. Default to the name
+// of the running program.
+func WithSyslogAppName(name string) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.app = syslogField(name, 48)
+	}
+}
+
+// WithSyslogHostname set HOSTNAME of each syslog message. Default to the host
+// name reported by the kernel.
+func WithSyslogHostname(host string) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.host = syslogField(host, 255)
+	}
+}
+
+// WithSyslogFraming set how each syslog message is delimited when sent over
+// stream based network. Default to OctetCounting.
+func WithSyslogFraming(f SyslogFraming) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.framing = f
+	}
+}
+
+// WithSyslogTLS set tls config used when the syslog network is tls.
+func WithSyslogTLS(cnf *tls.Config) ConfigOpt {
+	return func(c *Config) {
+		c.syslog.tls = cnf
+	}
+}
+
+// WithHTTPURL set URL of the HTTP endpoint the logs are sent to.
+func WithHTTPURL(url string) ConfigOpt {
+	return func(c *Config) {
+		c.http.url = url
+	}
+}
+
+// WithHTTPHeader set header sent along with each request e.g. Authorization.
+func WithHTTPHeader(key, val string) ConfigOpt {
+	return func(c *Config) {
+		if c.http.header == nil {
+			c.http.header = make(http.Header)
+		}
+		c.http.header.Set(key, val)
+	}
+}
+
+// WithHTTPBatchSize set maximum number of logs in a batch before sent. Default
+// to 500.
+func WithHTTPBatchSize(n int) ConfigOpt {
+	return func(c *Config) {
+		c.http.count = n
+	}
+}
+
+// WithHTTPBatchBytes set maximum size of logs in bytes in a batch before sent.
+// Default to 1MB.
+func WithHTTPBatchBytes(n int) ConfigOpt {
+	return func(c *Config) {
+		c.http.bytes = n
+	}
+}
+
+// WithHTTPBatchInterval set interval to send the current batch regardless of
+// its size. Default to 1 second.
+func WithHTTPBatchInterval(dur time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.http.interval = dur
+	}
+}
+
+// WithHTTPGzip set whether the request body should be compressed using gzip.
+// Default to true.
+func WithHTTPGzip(enable bool) ConfigOpt {
+	return func(c *Config) {
+		c.http.noGzip = !enable
+	}
+}
+
+// WithHTTPRetry set maximum number of retries and the initial backoff that is
+// doubled on each retry when the request failed due to network error, server
+// error or rate limit. Set max to -1 to disable retry. Default to 5 retries
+// starting from 500ms.
+func WithHTTPRetry(max int, backoff time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.http.retry = max
+		c.http.backoff = backoff
+	}
+}
+
+// WithHTTPClient set http.Client used to send the requests. Default to
+// http.Client with 10 seconds timeout.
+func WithHTTPClient(cl *http.Client) ConfigOpt {
+	return func(c *Config) {
+		c.http.client = cl
+	}
+}
+
+// WithHTTPErrorHandler set function that is called with the error whenever a
+// batch failed to be sent after all the retries, so the logs are dropped.
+func WithHTTPErrorHandler(fn func(error)) ConfigOpt {
+	return func(c *Config) {
+		c.http.onError = fn
+	}
+}
+
+// WithLokiURL set URL of grafana loki e.g. 'http://localhost:3100'. The push
+// API path is appended if the URL has no path. Default to
+// 'http://localhost:3100'.
+func WithLokiURL(url string) ConfigOpt {
+	return func(c *Config) {
+		c.loki.url = url
+	}
+}
+
+// WithLokiTenant set tenant ID sent as X-Scope-OrgID header when loki runs in
+// multi-tenant mode.
+func WithLokiTenant(id string) ConfigOpt {
+	return func(c *Config) {
+		c.loki.tenant = id
+	}
+}
+
+// WithLokiLabels set log fields whose value are used as the stream labels
+// e.g. app, env, level. Nested field can be set using its dot separated path
+// e.g. 'context.app'. Default to level only.
+func WithLokiLabels(fields ...string) ConfigOpt {
+	return func(c *Config) {
+		c.loki.labels = append(c.loki.labels, fields...)
+	}
+}
+
+// WithLokiStaticLabel set label that is attached to every stream e.g. env.
+func WithLokiStaticLabel(key, val string) ConfigOpt {
+	return func(c *Config) {
+		if c.loki.static == nil {
+			c.loki.static = make(map[string]string)
+		}
+		c.loki.static[key] = val
+	}
+}
+
+// WithLokiProtobuf set whether to push the logs using snappy compressed
+// protobuf instead of JSON. Default to false.
+func WithLokiProtobuf(enable bool) ConfigOpt {
+	return func(c *Config) {
+		c.loki.protobuf = enable
+	}
+}
+
+// WithOTLPEndpoint set URL of OTLP/HTTP endpoint e.g. 'http://localhost:4318'.
+// The logs path is appended if the URL has no path. Default to
+// 'http://localhost:4318'.
+func WithOTLPEndpoint(url string) ConfigOpt {
+	return func(c *Config) {
+		c.otlp.endpoint = url
+	}
+}
+
+// WithOTLPServiceName set service.name resource attribute. Default to the
+// name of the running program.
+func WithOTLPServiceName(name string) ConfigOpt {
+	return func(c *Config) {
+		c.otlp.service = name
+	}
+}
+
+// WithOTLPResourceAttr set resource attribute attached to every log record
+// e.g. deployment.environment.
+func WithOTLPResourceAttr(key, val string) ConfigOpt {
+	return func(c *Config) {
+		if c.otlp.resource == nil {
+			c.otlp.resource = make(map[string]string)
+		}
+		c.otlp.resource[key] = val
+	}
+}
+
+// WithElasticsearchURL set URL of elasticsearch or opensearch e.g.
+// 'http://localhost:9200'. The bulk API path is appended if the URL has no
+// path. Default to 'http://localhost:9200'.
+func WithElasticsearchURL(url string) ConfigOpt {
+	return func(c *Config) {
+		c.es.url = url
+	}
+}
+
+// WithElasticsearchIndex set index name pattern formatted using the log time
+// in UTC e.g. 'apilog-%Y.%m.%d'. Supported verbs are %Y, %m, %d, %H, %M and
+// %%. Default to 'apilog-%Y.%m.%d'.
+func WithElasticsearchIndex(pattern string) ConfigOpt {
+	return func(c *Config) {
+		c.es.index = pattern
+	}
+}
+
+// WithElasticsearchBasicAuth set username and password used to authenticate
+// using basic auth.
+func WithElasticsearchBasicAuth(user, pass string) ConfigOpt {
+	return func(c *Config) {
+		c.es.user = user
+		c.es.pass = pass
+	}
+}
+
+// WithElasticsearchAPIKey set base64 encoded API key used to authenticate.
+// Take precedence over basic auth.
+func WithElasticsearchAPIKey(key string) ConfigOpt {
+	return func(c *Config) {
+		c.es.apiKey = key
+	}
+}
+
+// WithFluentNetwork set network used to connect to fluentd or fluent bit
+// e.g. tcp, unix. Default to tcp.
+func WithFluentNetwork(network string) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.network = network
+	}
+}
+
+// WithFluentAddress set address of fluentd or fluent bit forward input e.g.
+// 'localhost:24224' or path of the unix socket. Default to 'localhost:24224'.
+func WithFluentAddress(addr string) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.addr = addr
+	}
+}
+
+// WithFluentTag set tag of the forwarded logs used for routing e.g.
+// 'app.api'. Default to 'apilog'.
+func WithFluentTag(tag string) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.tag = tag
+	}
+}
+
+// WithFluentAck set whether to wait for the ack of each batch, so a batch is
+// resent if it is not acknowledged. Require require_ack_response in fluentd
+// or Require_ack_response in fluent bit. Default to false.
+func WithFluentAck(enable bool) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.ack = enable
+	}
+}
+
+// WithFluentBatchSize set maximum number of logs in a single batch. Default to
+// 500.
+func WithFluentBatchSize(n int) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.count = n
+	}
+}
+
+// WithFluentBatchInterval set how often the current batch is sent even if it
+// is not full yet. Default to 1 second.
+func WithFluentBatchInterval(dur time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.interval = dur
+	}
+}
+
+// WithFluentRetry set maximum number of reconnect attempts and the initial
+// backoff that is doubled on each attempt. Set max to -1 to disable retry.
+// Default to 5 retries and 500ms.
+func WithFluentRetry(max int, backoff time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.retry = max
+		c.fluent.backoff = backoff
+	}
+}
+
+// WithFluentErrorHandler set function that is called with the error of each
+// batch that failed to be sent after all retries.
+func WithFluentErrorHandler(fn func(error)) ConfigOpt {
+	return func(c *Config) {
+		c.fluent.onError = fn
+	}
+}
+
+// WithGELFNetwork set network used to send logs to graylog e.g. udp, tcp.
+// Default to udp.
+func WithGELFNetwork(network string) ConfigOpt {
+	return func(c *Config) {
+		c.gelf.network = network
+	}
+}
+
+// WithGELFAddress set address of graylog GELF input. Default to
+// 'localhost:12201'.
+func WithGELFAddress(addr string) ConfigOpt {
+	return func(c *Config) {
+		c.gelf.addr = addr
+	}
+}
+
+// WithGELFHostname set host field of each GELF message. Default to the
+// hostname of the running machine.
+func WithGELFHostname(host string) ConfigOpt {
+	return func(c *Config) {
+		c.gelf.host = host
+	}
+}
+
+// WithGELFCompression set how each message is compressed when sent over udp.
+// Default to GELFGzip.
+func WithGELFCompression(comp GELFCompression) ConfigOpt {
+	return func(c *Config) {
+		c.gelf.compress = comp
+	}
+}
+
+// WithGELFChunkSize set maximum size in bytes of each udp datagram, larger
+// message is chunked. Default to 1420 which is safe for most networks, use
+// e.g. 8192 within local network.
+func WithGELFChunkSize(n int) ConfigOpt {
+	return func(c *Config) {
+		c.gelf.chunk = n
+	}
+}
+
+// WithSplunkURL set URL of splunk HTTP Event Collector e.g.
+// 'https://splunk:8088'. The event endpoint path is appended if the URL has
+// no path. Default to 'https://localhost:8088'.
+func WithSplunkURL(url string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.url = url
+	}
+}
+
+// WithSplunkToken set HEC token used to authenticate.
+func WithSplunkToken(token string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.token = token
+	}
+}
+
+// WithSplunkHost set host of each event. Default to the hostname of the
+// running machine.
+func WithSplunkHost(host string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.host = host
+	}
+}
+
+// WithSplunkSource set source of each event. Default to the token's default.
+func WithSplunkSource(source string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.source = source
+	}
+}
+
+// WithSplunkSourcetype set sourcetype of each event. Default to '_json'.
+func WithSplunkSourcetype(sourcetype string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.sourcetype = sourcetype
+	}
+}
+
+// WithSplunkIndex set index each event is sent to. Default to the token's
+// default index.
+func WithSplunkIndex(index string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.index = index
+	}
+}
+
+// WithSplunkFields set log fields whose value are also sent as indexed fields
+// e.g. app, env. Nested field can be set using its dot separated path e.g.
+// 'context.app'.
+func WithSplunkFields(fields ...string) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.fields = append(c.splunk.fields, fields...)
+	}
+}
+
+// WithSplunkStaticField set indexed field that is attached to every event
+// e.g. env.
+func WithSplunkStaticField(key, val string) ConfigOpt {
+	return func(c *Config) {
+		if c.splunk.static == nil {
+			c.splunk.static = make(map[string]string)
+		}
+		c.splunk.static[key] = val
+	}
+}
+
+// WithSplunkAck enable indexer acknowledgement by waiting up to given timeout
+// for each batch to be indexed, otherwise it's resent. Error response of the
+// ack endpoint is reported without resending. Require indexer acknowledgement
+// to be enabled for the token. Disabled by default or if given timeout is not
+// positive.
+func WithSplunkAck(timeout time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.splunk.ack = max(timeout, 0)
+	}
+}
+
+// WithDatadogURL set URL of datadog logs intake API based on the datadog site
+// e.g. 'https://http-intake.logs.datadoghq.eu'. The logs path is appended if
+// the URL has no path. Default to 'https://http-intake.logs.datadoghq.com'.
+func WithDatadogURL(url string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.url = url
+	}
+}
+
+// WithDatadogAPIKey set datadog API key used to authenticate.
+func WithDatadogAPIKey(key string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.apiKey = key
+	}
+}
+
+// WithDatadogService set service of each log. Default to the name of the
+// running program.
+func WithDatadogService(name string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.service = name
+	}
+}
+
+// WithDatadogSource set ddsource of each log used to select the integration
+// pipeline. Default to 'go'.
+func WithDatadogSource(source string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.source = source
+	}
+}
+
+// WithDatadogTags set ddtags of each log e.g. 'env:prod', 'version:1.0'.
+func WithDatadogTags(tags ...string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.tags = append(c.datadog.tags, tags...)
+	}
+}
+
+// WithDatadogHostname set hostname of each log. Default to the hostname of
+// the running machine.
+func WithDatadogHostname(host string) ConfigOpt {
+	return func(c *Config) {
+		c.datadog.host = host
+	}
+}
+
+// WithKafkaProducer set the producer used to produce the logs to kafka.
+// Required by NewKafkaWriter.
+func WithKafkaProducer(p KafkaProducer) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.producer = p
+	}
+}
+
+// WithKafkaTopic set topic the logs are produced to. Default to 'apilog'.
+func WithKafkaTopic(topic string) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.topic = topic
+	}
+}
+
+// WithKafkaKey set log field whose value is used as the message key to select
+// the partition e.g. tenant_id. Nested field can be set using its dot
+// separated path e.g. 'context.tenant_id'.
+func WithKafkaKey(field string) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.key = field
+	}
+}
+
+// WithKafkaBatchSize set maximum number of logs in a single batch. Default to
+// 500.
+func WithKafkaBatchSize(n int) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.count = n
+	}
+}
+
+// WithKafkaBatchBytes set maximum total size in bytes of logs in a single
+// batch. Default to 1MB.
+func WithKafkaBatchBytes(n int) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.bytes = n
+	}
+}
+
+// WithKafkaLinger set how long the current batch may wait before it's
+// produced even if it is not full yet. Default to 1 second.
+func WithKafkaLinger(dur time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.linger = dur
+	}
+}
+
+// WithKafkaErrorHandler set function that is called with the error of each
+// batch that failed to be delivered.
+func WithKafkaErrorHandler(fn func(error)) ConfigOpt {
+	return func(c *Config) {
+		c.kafka.onError = fn
+	}
+}
+
+// WithJournaldSocket set path of journald native protocol socket. Default to
+// '/run/systemd/journal/socket'.
+func WithJournaldSocket(path string) ConfigOpt {
+	return func(c *Config) {
+		c.journald.socket = path
+	}
+}
+
+// WithJournaldIdentifier set SYSLOG_IDENTIFIER of each entry used by
+// journalctl -t. Default to the name of the running program.
+func WithJournaldIdentifier(id string) ConfigOpt {
+	return func(c *Config) {
+		c.journald.identifier = id
+	}
+}
+
+// WithNetworkAddress set network and address the logs are sent to e.g. tcp
+// and 'localhost:9000', udp, unix or unixgram and path of the socket. Default
+// to tcp and 'localhost:9000'.
+func WithNetworkAddress(network, addr string) ConfigOpt {
+	return func(c *Config) {
+		c.network.network = network
+		c.network.addr = addr
+	}
+}
+
+// WithNetworkTLS set TLS config used to connect over tcp, set its
+// Certificates for client certificate authentication. TLS is not used if
+// nil.
+func WithNetworkTLS(cnf *tls.Config) ConfigOpt {
+	return func(c *Config) {
+		c.network.tls = cnf
+	}
+}
+
+// WithNetworkBuffer set maximum total size in bytes of logs buffered while
+// they are waiting to be sent e.g. while disconnected. Default to 1MB.
+func WithNetworkBuffer(n int) ConfigOpt {
+	return func(c *Config) {
+		c.network.buffer = n
+	}
+}
+
+// WithNetworkBackoff set initial wait time before reconnecting that is
+// doubled on each failed attempt up to 30 seconds. Default to 500ms.
+func WithNetworkBackoff(dur time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.network.backoff = dur
+	}
+}
+
+// WithNetworkErrorHandler set function that is called with the error of each
+// failed connect or write attempt, and of the logs dropped on flush.
+func WithNetworkErrorHandler(fn func(error)) ConfigOpt {
+	return func(c *Config) {
+		c.network.onError = fn
+	}
+}
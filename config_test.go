@@ -1,7 +1,12 @@
 package apilog
 
 import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +20,86 @@ func TestNewConfig(t *testing.T) {
 			WithFileSize(100),
 			WithFileAge(7),
 			WithFileMaxBackup(7),
+			WithFileRotation(24*time.Hour),
+			WithFilePattern("/var/log/app-%Y-%m-%d.log"),
+			WithFileCompress(ZstdCompression),
+			WithFileMode(0640),
+			WithDirMode(0750),
+			WithFileOwner(1000, 1001),
+			WithHTTPURL("http://localhost/logs"),
+			WithHTTPHeader("Authorization", "Bearer token"),
+			WithHTTPBatchSize(10),
+			WithHTTPBatchBytes(1024),
+			WithHTTPBatchInterval(time.Minute),
+			WithHTTPGzip(false),
+			WithHTTPRetry(3, time.Second),
+			WithHTTPClient(http.DefaultClient),
+			WithHTTPErrorHandler(func(error) {}),
+			WithLokiURL("http://loki:3100"),
+			WithLokiTenant("team-a"),
+			WithLokiLabels("app", "level"),
+			WithLokiStaticLabel("env", "prod"),
+			WithLokiProtobuf(true),
+			WithOTLPEndpoint("http://collector:4318"),
+			WithOTLPServiceName("api"),
+			WithOTLPResourceAttr("deployment.environment", "prod"),
+			WithElasticsearchURL("http://es:9200"),
+			WithElasticsearchIndex("logs-%Y.%m"),
+			WithElasticsearchBasicAuth("user", "pass"),
+			WithElasticsearchAPIKey("key"),
+			WithFluentNetwork("unix"),
+			WithFluentAddress("/var/run/fluent.sock"),
+			WithFluentTag("app.api"),
+			WithFluentAck(true),
+			WithFluentBatchSize(100),
+			WithFluentBatchInterval(2*time.Second),
+			WithFluentRetry(3, time.Second),
+			WithFluentErrorHandler(func(error) {}),
+			WithGELFNetwork("tcp"),
+			WithGELFAddress("graylog:12201"),
+			WithGELFHostname("host"),
+			WithGELFCompression(GELFZlib),
+			WithGELFChunkSize(8192),
+			WithSplunkURL("https://splunk:8088"),
+			WithSplunkToken("token"),
+			WithSplunkHost("host"),
+			WithSplunkSource("api"),
+			WithSplunkSourcetype("apilog"),
+			WithSplunkIndex("main"),
+			WithSplunkFields("app", "env"),
+			WithSplunkStaticField("region", "eu"),
+			WithSplunkAck(time.Minute),
+			WithDatadogURL("https://http-intake.logs.datadoghq.eu"),
+			WithDatadogAPIKey("key"),
+			WithDatadogService("api"),
+			WithDatadogSource("apilog"),
+			WithDatadogTags("env:prod", "version:1.0"),
+			WithDatadogHostname("host"),
+			WithKafkaProducer(&fakeProducer{}),
+			WithKafkaTopic("audit"),
+			WithKafkaKey("tenant_id"),
+			WithKafkaBatchSize(100),
+			WithKafkaBatchBytes(1<<10),
+			WithKafkaLinger(10*time.Millisecond),
+			WithKafkaErrorHandler(func(error) {}),
+			WithJournaldSocket("/tmp/journal.sock"),
+			WithJournaldIdentifier("api"),
+			WithNetworkAddress("udp", "vector:9000"),
+			WithNetworkTLS(&tls.Config{ServerName: "vector"}),
+			WithNetworkBuffer(1<<10),
+			WithNetworkBackoff(time.Second),
+			WithNetworkErrorHandler(func(error) {}),
+			WithRedactKeys("password"),
+			WithSyslogNetwork("tcp"),
+			WithSyslogAddress("localhost:601"),
+			WithSyslogFacility(SyslogLocal3),
+			WithSyslogAppName("my app"),
+			WithSyslogHostname("host"),
+			WithSyslogFraming(NewlineFraming),
+			WithSyslogTLS(&tls.Config{ServerName: "syslog"}),
+			WithRedactKeyPattern(regexp.MustCompile(`token$`)),
+			WithRedactValuePattern(regexp.MustCompile(`\d{16}`)),
+			WithRedactMasker(MaskHash),
 		)
 
 		// assert all values
@@ -24,5 +109,96 @@ func TestNewConfig(t *testing.T) {
 		assert.Equal(t, 100, cnf.file.size)
 		assert.Equal(t, 7, cnf.file.age)
 		assert.Equal(t, 7, cnf.file.num)
+		assert.Equal(t, 24*time.Hour, cnf.file.every)
+		assert.Equal(t, "/var/log/app-%Y-%m-%d.log", cnf.file.pattern)
+		assert.Equal(t, ZstdCompression, cnf.file.compress)
+		assert.Equal(t, os.FileMode(0640), cnf.file.fileMode)
+		assert.Equal(t, os.FileMode(0750), cnf.file.dirMode)
+		assert.True(t, cnf.file.chown)
+		assert.Equal(t, 1000, cnf.file.uid)
+		assert.Equal(t, 1001, cnf.file.gid)
+		assert.Equal(t, "http://localhost/logs", cnf.http.url)
+		assert.Equal(t, "Bearer token", cnf.http.header.Get("Authorization"))
+		assert.Equal(t, 10, cnf.http.count)
+		assert.Equal(t, 1024, cnf.http.bytes)
+		assert.Equal(t, time.Minute, cnf.http.interval)
+		assert.True(t, cnf.http.noGzip)
+		assert.Equal(t, 3, cnf.http.retry)
+		assert.Equal(t, time.Second, cnf.http.backoff)
+		assert.Equal(t, http.DefaultClient, cnf.http.client)
+		assert.NotNil(t, cnf.http.onError)
+		assert.Equal(t, "http://loki:3100", cnf.loki.url)
+		assert.Equal(t, "team-a", cnf.loki.tenant)
+		assert.Equal(t, []string{"app", "level"}, cnf.loki.labels)
+		assert.Equal(t, map[string]string{"env": "prod"}, cnf.loki.static)
+		assert.True(t, cnf.loki.protobuf)
+		assert.Equal(t, "http://collector:4318", cnf.otlp.endpoint)
+		assert.Equal(t, "api", cnf.otlp.service)
+		assert.Equal(t, map[string]string{"deployment.environment": "prod"}, cnf.otlp.resource)
+		assert.Equal(t, "http://es:9200", cnf.es.url)
+		assert.Equal(t, "logs-%Y.%m", cnf.es.index)
+		assert.Equal(t, "user", cnf.es.user)
+		assert.Equal(t, "pass", cnf.es.pass)
+		assert.Equal(t, "key", cnf.es.apiKey)
+		assert.Equal(t, "unix", cnf.fluent.network)
+		assert.Equal(t, "/var/run/fluent.sock", cnf.fluent.addr)
+		assert.Equal(t, "app.api", cnf.fluent.tag)
+		assert.True(t, cnf.fluent.ack)
+		assert.Equal(t, 100, cnf.fluent.count)
+		assert.Equal(t, 2*time.Second, cnf.fluent.interval)
+		assert.Equal(t, 3, cnf.fluent.retry)
+		assert.Equal(t, time.Second, cnf.fluent.backoff)
+		assert.NotNil(t, cnf.fluent.onError)
+		assert.Equal(t, "tcp", cnf.gelf.network)
+		assert.Equal(t, "graylog:12201", cnf.gelf.addr)
+		assert.Equal(t, "host", cnf.gelf.host)
+		assert.Equal(t, GELFZlib, cnf.gelf.compress)
+		assert.Equal(t, 8192, cnf.gelf.chunk)
+		assert.Equal(t, "https://splunk:8088", cnf.splunk.url)
+		assert.Equal(t, "token", cnf.splunk.token)
+		assert.Equal(t, "host", cnf.splunk.host)
+		assert.Equal(t, "api", cnf.splunk.source)
+		assert.Equal(t, "apilog", cnf.splunk.sourcetype)
+		assert.Equal(t, "main", cnf.splunk.index)
+		assert.Equal(t, []string{"app", "env"}, cnf.splunk.fields)
+		assert.Equal(t, map[string]string{"region": "eu"}, cnf.splunk.static)
+		assert.Equal(t, time.Minute, cnf.splunk.ack)
+		assert.Equal(t, "https://http-intake.logs.datadoghq.eu", cnf.datadog.url)
+		assert.Equal(t, "key", cnf.datadog.apiKey)
+		assert.Equal(t, "api", cnf.datadog.service)
+		assert.Equal(t, "apilog", cnf.datadog.source)
+		assert.Equal(t, []string{"env:prod", "version:1.0"}, cnf.datadog.tags)
+		assert.Equal(t, "host", cnf.datadog.host)
+		assert.Equal(t, &fakeProducer{}, cnf.kafka.producer)
+		assert.Equal(t, "audit", cnf.kafka.topic)
+		assert.Equal(t, "tenant_id", cnf.kafka.key)
+		assert.Equal(t, 100, cnf.kafka.count)
+		assert.Equal(t, 1<<10, cnf.kafka.bytes)
+		assert.Equal(t, 10*time.Millisecond, cnf.kafka.linger)
+		assert.NotNil(t, cnf.kafka.onError)
+		assert.Equal(t, "/tmp/journal.sock", cnf.journald.socket)
+		assert.Equal(t, "api", cnf.journald.identifier)
+		assert.Equal(t, "udp", cnf.network.network)
+		assert.Equal(t, "vector:9000", cnf.network.addr)
+		assert.Equal(t, "vector", cnf.network.tls.ServerName)
+		assert.Equal(t, 1<<10, cnf.network.buffer)
+		assert.Equal(t, time.Second, cnf.network.backoff)
+		assert.NotNil(t, cnf.network.onError)
+		assert.Equal(t, []string{"password"}, cnf.redact.keys)
+		assert.Equal(t, "tcp", cnf.syslog.network)
+		assert.Equal(t, "localhost:601", cnf.syslog.addr)
+		assert.Equal(t, SyslogLocal3, cnf.syslog.facility)
+		assert.Equal(t, "my_app", cnf.syslog.app)
+		assert.Equal(t, "host", cnf.syslog.host)
+		assert.Equal(t, NewlineFraming, cnf.syslog.framing)
+		assert.Equal(t, "syslog", cnf.syslog.tls.ServerName)
+		assert.Equal(t, "token$", cnf.redact.keyRe[0].String())
+		assert.Equal(t, `\d{16}`, cnf.redact.valueRe[0].String())
+		assert.Equal(t, MaskHash("x"), cnf.redact.masker("x"))
+	})
+
+	t.Run("Given negative splunk ack timeout should disable it", func(t *testing.T) {
+		cnf := NewConfig(WithSplunkAck(-time.Second))
+		assert.Zero(t, cnf.splunk.ack)
 	})
 }
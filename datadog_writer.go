@@ -0,0 +1,161 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// datadogLogsPath path of datadog logs intake API.
+const datadogLogsPath = "/api/v2/logs"
+
+const (
+	// datadogMaxPayload maximum size in bytes of a single uncompressed payload
+	// accepted by datadog logs intake API.
+	datadogMaxPayload = 5 << 20
+	// datadogMaxEntries maximum number of logs in a single payload accepted by
+	// datadog logs intake API.
+	datadogMaxEntries = 1000
+)
+
+// datadogStatus datadog status of each syslog severity.
+var datadogStatus = [...]string{
+	syslogEmergency: "emergency",
+	syslogAlert:     "alert",
+	syslogCritical:  "critical",
+	syslogError:     "error",
+	syslogWarning:   "warning",
+	syslogNotice:    "notice",
+	syslogInfo:      "info",
+	syslogDebug:     "debug",
+}
+
+// NewDatadogWriter return Writer implementer that send logs in batches to
+// datadog logs intake API by given Config and set given lvl as the log Level.
+// The Level, message and time of each log are mapped to datadog reserved
+// attributes, the existing field of the same name is moved to 'attr.<name>',
+// and each batch is split if it exceeds the payload limit. Batching, retry,
+// headers and error handling are configured using the same options as
+// NewHTTPWriter e.g. WithHTTPBatchSize, except the URL.
+func NewDatadogWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	d := &datadogEncoder{cnf: cnf.datadog, maxBytes: datadogMaxPayload, maxEntries: datadogMaxEntries}
+	// set default value
+	if d.cnf.service == "" {
+		d.cnf.service = filepath.Base(os.Args[0])
+	}
+	if d.cnf.source == "" {
+		d.cnf.source = "go"
+	}
+	if d.cnf.host == "" {
+		d.cnf.host, _ = os.Hostname()
+	}
+	d.tags = strings.Join(d.cnf.tags, ",")
+
+	hc := cnf.http
+	hc.url = endpointURL(cnf.datadog.url, "https://http-intake.logs.datadoghq.com", datadogLogsPath)
+	hc.contentType = "application/json"
+	hc.header = hc.header.Clone()
+	if hc.header == nil {
+		hc.header = make(http.Header)
+	}
+	if cnf.datadog.apiKey != "" {
+		hc.header.Set("DD-API-KEY", cnf.datadog.apiKey)
+	}
+
+	h := newHTTPBatcher(DATADOG, lvl, hc, encodeJSONArray)
+	h.split = d.split
+	return h
+}
+
+// datadogEncoder convert the logs to datadog logs then split the batch based
+// on the payload limit.
+type datadogEncoder struct {
+	cnf        DatadogConfig
+	tags       string
+	maxBytes   int
+	maxEntries int
+}
+
+// split convert given entries to datadog logs then split them so each batch
+// does not exceed the maximum number of logs and the maximum payload size
+// once encoded as JSON array. Log that alone exceeds the maximum payload size
+// is sent in its own batch.
+func (d *datadogEncoder) split(entries [][]byte) [][][]byte {
+	var batches [][][]byte
+	var batch [][]byte
+	size := 2 // brackets
+	for _, e := range entries {
+		e = d.convert(e)
+		if len(batch) > 0 && (len(batch) >= d.maxEntries || size+1+len(e) > d.maxBytes) {
+			batches = append(batches, batch)
+			batch, size = nil, 2
+		}
+		if len(batch) > 0 {
+			size++ // comma
+		}
+		batch = append(batch, e)
+		size += len(e)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// convert return given JSON encoded log after mapping its Level, message and
+// time to datadog status, message and timestamp, then adding the service,
+// source, tags and hostname unless already set. The log is used as the
+// message as is if it's not a JSON object.
+func (d *datadogEncoder) convert(e []byte) []byte {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(e))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		fields = map[string]any{"message": string(e)}
+	}
+
+	if _, ok := fields["level"]; ok {
+		datadogReserve(fields, "status", datadogStatus[toSyslogSeverity(levelOf(e))])
+		delete(fields, "level")
+	}
+	if msg, ok := fields["msg"]; ok {
+		delete(fields, "msg")
+		datadogReserve(fields, "message", msg)
+	}
+	if _, ok := fields["time"]; ok {
+		datadogReserve(fields, "timestamp", timeOf(fields).UnixMilli())
+		delete(fields, "time")
+	}
+	for k, v := range map[string]string{
+		"service":  d.cnf.service,
+		"ddsource": d.cnf.source,
+		"ddtags":   d.tags,
+		"hostname": d.cnf.host,
+	} {
+		if _, ok := fields[k]; !ok && v != "" {
+			fields[k] = v
+		}
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return e
+	}
+	return b
+}
+
+// datadogReserve set given reserved attribute k to v. The existing field of
+// the same name is moved aside to 'attr.<k>', so it is not silently lost.
+func datadogReserve(fields map[string]any, k string, v any) {
+	if old, ok := fields[k]; ok {
+		fields["attr."+k] = old
+	}
+	fields[k] = v
+}
@@ -0,0 +1,158 @@
+package apilog
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDatadogWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewDatadogWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.Equal(t, DATADOG, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "https://http-intake.logs.datadoghq.com/api/v2/logs", h.cnf.url)
+		assert.Empty(t, h.cnf.header.Get("DD-API-KEY"))
+		assert.NotNil(t, h.split)
+	})
+}
+
+func TestDatadogEncoder(t *testing.T) {
+	d := &datadogEncoder{
+		cnf:  DatadogConfig{service: "api", source: "go", host: "host"},
+		tags: "env:prod,version:1.0",
+	}
+
+	t.Run("Should map the reserved attributes", func(t *testing.T) {
+		b := d.convert([]byte(`{"level":"WARNING","time":"2024-08-28T07:59:13.259+07:00","msg":"hello","code":200}`))
+		assert.JSONEq(t, `{
+			"status":"warning",
+			"timestamp":1724806753259,
+			"message":"hello",
+			"code":200,
+			"service":"api",
+			"ddsource":"go",
+			"ddtags":"env:prod,version:1.0",
+			"hostname":"host"
+		}`, string(b))
+	})
+
+	t.Run("Given log that already has the reserved attribute should keep it", func(t *testing.T) {
+		b := d.convert([]byte(`{"level":"FATAL","msg":"hello","service":"worker"}`))
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(b, &fields))
+		assert.Equal(t, "worker", fields["service"])
+		assert.Equal(t, "alert", fields["status"])
+	})
+
+	t.Run("Given field that collide with the mapped attribute should move it aside", func(t *testing.T) {
+		b := d.convert([]byte(`{"level":"INFO","time":"2024-08-28T07:59:13.259+07:00","msg":"hello","status":201,"message":"created","timestamp":"yesterday"}`))
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(b, &fields))
+		assert.Equal(t, "info", fields["status"])
+		assert.Equal(t, "hello", fields["message"])
+		assert.Equal(t, float64(1724806753259), fields["timestamp"])
+		assert.Equal(t, float64(201), fields["attr.status"])
+		assert.Equal(t, "created", fields["attr.message"])
+		assert.Equal(t, "yesterday", fields["attr.timestamp"])
+	})
+
+	t.Run("Given not a JSON object should use it as the message as is", func(t *testing.T) {
+		b := d.convert([]byte(`plain text`))
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(b, &fields))
+		assert.Equal(t, "plain text", fields["message"])
+		assert.NotContains(t, fields, "status")
+	})
+
+	t.Run("Should split the batch based on the maximum number of logs and payload size", func(t *testing.T) {
+		entry := []byte(`{"msg":"hello"}`)
+		size := len(d.convert(entry))
+
+		testCases := []struct {
+			name       string
+			maxBytes   int
+			maxEntries int
+			entries    int
+			expect     []int
+		}{
+			{name: "Within the limit", maxBytes: 1 << 20, maxEntries: 10, entries: 5, expect: []int{5}},
+			{name: "Exceed the maximum number of logs", maxBytes: 1 << 20, maxEntries: 2, entries: 5, expect: []int{2, 2, 1}},
+			{name: "Exceed the maximum payload size", maxBytes: 2 + size*3 + 2, maxEntries: 10, entries: 7, expect: []int{3, 3, 1}},
+			{name: "Single log exceed the maximum payload size", maxBytes: 1, maxEntries: 10, entries: 2, expect: []int{1, 1}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				e := *d
+				e.maxBytes, e.maxEntries = tc.maxBytes, tc.maxEntries
+				entries := make([][]byte, tc.entries)
+				for i := range entries {
+					entries[i] = entry
+				}
+
+				var got []int
+				for _, b := range e.split(entries) {
+					got = append(got, len(b))
+					if tc.maxBytes > size+2 {
+						body, _ := encodeJSONArray(b)
+						assert.LessOrEqual(t, len(body), tc.maxBytes)
+					}
+				}
+				assert.Equal(t, tc.expect, got)
+			})
+		}
+	})
+}
+
+func TestDatadogWriter(t *testing.T) {
+	t.Run("Should send each split batch in its own request", func(t *testing.T) {
+		srv, ch := newHTTPServer(t, func(int) int { return http.StatusAccepted })
+		wr := NewDatadogWriter(InfoLevel, NewConfig(
+			WithDatadogURL(srv.URL),
+			WithDatadogAPIKey("secret"),
+			WithDatadogTags("env:prod"),
+			WithHTTPBatchSize(2000),
+			WithHTTPBatchBytes(10<<20),
+		))
+		for i := 0; i < 1500; i++ {
+			_, _ = wr.Writer().Write([]byte(`{"level":"INFO","msg":"hello"}`))
+		}
+		wr.Flush(time.Second)
+
+		first := receiveRequest(t, ch)
+		second := receiveRequest(t, ch)
+		assert.Equal(t, "secret", first.header.Get("DD-API-KEY"))
+		logs := decodeBatch(t, first.body)
+		assert.Len(t, logs, datadogMaxEntries)
+		assert.Len(t, decodeBatch(t, second.body), 500)
+		assert.Equal(t, "info", logs[0]["status"])
+		assert.Equal(t, "hello", logs[0]["message"])
+		assert.Equal(t, "env:prod", logs[0]["ddtags"])
+	})
+
+	t.Run("Should report the error of each failed split batch", func(t *testing.T) {
+		srv, _ := newHTTPServer(t, func(int) int { return http.StatusForbidden })
+		var reported []error
+		wr := NewDatadogWriter(InfoLevel, NewConfig(
+			WithDatadogURL(srv.URL),
+			WithHTTPBatchSize(2000),
+			WithHTTPErrorHandler(func(err error) { reported = append(reported, err) }),
+		))
+		for i := 0; i < 1001; i++ {
+			_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		}
+		wr.Flush(time.Second)
+
+		require.Len(t, reported, 2)
+		assert.True(t, strings.HasPrefix(reported[0].Error(), "failed to send 1000 logs"))
+		assert.True(t, strings.HasPrefix(reported[1].Error(), "failed to send 1 logs"))
+	})
+}
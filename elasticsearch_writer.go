@@ -0,0 +1,131 @@
+package apilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// esBulkPath path of elasticsearch bulk API.
+const esBulkPath = "/_bulk"
+
+// NewElasticsearchWriter return Writer implementer that index logs in batches
+// to elasticsearch or opensearch using the bulk API by given Config and set
+// given lvl as the log Level. Each log is indexed to the index whose name is
+// formatted from the index pattern using the log time in UTC. Rejected logs
+// are retried individually if the rejection is temporary e.g. 429, otherwise
+// dropped. Batching, retry and error handling are configured using the same
+// options as NewHTTPWriter e.g. WithHTTPBatchSize, except the URL.
+func NewElasticsearchWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	e := &esEncoder{index: cnf.es.index}
+	// set default value
+	if e.index == "" {
+		e.index = "apilog-%Y.%m.%d"
+	}
+
+	hc := cnf.http
+	hc.url = endpointURL(cnf.es.url, "http://localhost:9200", esBulkPath)
+	hc.contentType = "application/x-ndjson"
+	hc.header = hc.header.Clone()
+	if hc.header == nil {
+		hc.header = make(http.Header)
+	}
+	switch {
+	case cnf.es.apiKey != "":
+		hc.header.Set("Authorization", "ApiKey "+cnf.es.apiKey)
+	case cnf.es.user != "":
+		cred := base64.StdEncoding.EncodeToString([]byte(cnf.es.user + ":" + cnf.es.pass))
+		hc.header.Set("Authorization", "Basic "+cred)
+	}
+
+	h := newHTTPBatcher(ELASTICSEARCH, lvl, hc, e.encode)
+	h.partial = esPartial
+	return h
+}
+
+// esEncoder encode the batch as elasticsearch bulk request.
+type esEncoder struct {
+	index string
+}
+
+// encode given entries as newline delimited create action followed by the
+// log itself.
+func (e *esEncoder) encode(entries [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, en := range entries {
+		var fields map[string]any
+		_ = json.Unmarshal(en, &fields)
+
+		index, err := json.Marshal(formatFilePattern(e.index, timeOf(fields).UTC()))
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`{"create":{"_index":`)
+		buf.Write(index)
+		buf.WriteString("}}\n")
+		buf.Write(en)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// esBulkResponse response of elasticsearch bulk API.
+type esBulkResponse struct {
+	Errors bool                          `json:"errors"`
+	Items  []map[string]esBulkItemResult `json:"items"`
+}
+
+// esBulkItemResult result of each item in the bulk request.
+type esBulkItemResult struct {
+	Status int `json:"status"`
+	Error  struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// esPartial return the entries rejected by elasticsearch that should be
+// retried i.e. 429 or 5xx, along with the error of the dropped ones.
+func esPartial(_ context.Context, entries [][]byte, resp []byte) ([][]byte, int, error) {
+	var res esBulkResponse
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, 0, nil
+	}
+	if !res.Errors {
+		return nil, 0, nil
+	}
+
+	var retry [][]byte
+	var dropped int
+	var errs []error
+	for i, item := range res.Items {
+		if i >= len(entries) {
+			break
+		}
+		for _, r := range item {
+			switch {
+			case r.Status >= 200 && r.Status < 300:
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				retry = append(retry, entries[i])
+			default:
+				dropped++
+				// keep the first few errors only
+				if len(errs) < 3 {
+					errs = append(errs, errors.New(strconv.Itoa(r.Status)+" "+r.Error.Type+": "+r.Error.Reason))
+				}
+			}
+		}
+	}
+	if dropped == 0 {
+		return retry, 0, nil
+	}
+	return retry, dropped, errors.Join(errs...)
+}
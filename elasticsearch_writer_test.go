@@ -0,0 +1,207 @@
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// esTestDoc document decoded from elasticsearch bulk request.
+type esTestDoc struct {
+	index string
+	doc   map[string]any
+}
+
+// decodeBulk decode given bulk request.
+func decodeBulk(t *testing.T, b []byte) []esTestDoc {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+
+	var docs []esTestDoc
+	for sc.Scan() {
+		var action map[string]map[string]string
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &action))
+		require.True(t, sc.Scan())
+		d := esTestDoc{index: action["create"]["_index"]}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &d.doc))
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+func TestESEncoder(t *testing.T) {
+	t.Run("Should create each log in the index formatted using the log time in UTC", func(t *testing.T) {
+		e := &esEncoder{index: "logs-%Y.%m.%d"}
+		b, err := e.encode([][]byte{
+			[]byte(`{"msg":"first","time":"2026-10-17T23:59:13+07:00"}`),
+			[]byte(`{"msg":"second","time":"2026-10-18T07:59:13+07:00"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"create":{"_index":"logs-2026.10.17"}}
+{"msg":"first","time":"2026-10-17T23:59:13+07:00"}
+{"create":{"_index":"logs-2026.10.18"}}
+{"msg":"second","time":"2026-10-18T07:59:13+07:00"}
+`, string(b))
+	})
+}
+
+func TestESPartial(t *testing.T) {
+	entries := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")}
+
+	t.Run("Given no error should not retry anything", func(t *testing.T) {
+		retry, dropped, err := esPartial(context.Background(), entries, []byte(`{"errors":false,"items":[]}`))
+		assert.Empty(t, retry)
+		assert.Zero(t, dropped)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should retry the temporary rejected logs and drop the rest", func(t *testing.T) {
+		resp := `{"errors":true,"items":[
+			{"create":{"status":201}},
+			{"create":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"busy"}}},
+			{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}},
+			{"create":{"status":503,"error":{"type":"unavailable_shards_exception","reason":"unavailable"}}}
+		]}`
+		retry, dropped, err := esPartial(context.Background(), entries, []byte(resp))
+		assert.Equal(t, [][]byte{[]byte("b"), []byte("d")}, retry)
+		assert.Equal(t, 1, dropped)
+		assert.EqualError(t, err, "400 mapper_parsing_exception: failed to parse")
+	})
+}
+
+func TestNewElasticsearchWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewElasticsearchWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &httpBatcher{}, wr.Writer())
+		assert.Equal(t, ELASTICSEARCH, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "http://localhost:9200/_bulk", h.cnf.url)
+		assert.Equal(t, "application/x-ndjson", h.cnf.contentType)
+		assert.Empty(t, h.cnf.header.Get("Authorization"))
+	})
+
+	testCases := []struct {
+		name   string
+		opts   []ConfigOpt
+		expect string
+	}{
+		{
+			name:   "Given basic auth should set the Authorization header",
+			opts:   []ConfigOpt{WithElasticsearchBasicAuth("user", "pass")},
+			expect: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:   "Given API key should take precedence over basic auth",
+			opts:   []ConfigOpt{WithElasticsearchBasicAuth("user", "pass"), WithElasticsearchAPIKey("a2V5")},
+			expect: "ApiKey a2V5",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wr := NewElasticsearchWriter(InfoLevel, NewConfig(tc.opts...))
+			defer wr.Flush(-1)
+			assert.Equal(t, tc.expect, wr.(*httpBatcher).cnf.header.Get("Authorization"))
+		})
+	}
+}
+
+func TestElasticsearchWriter(t *testing.T) {
+	t.Run("Should only retry the temporary rejected logs", func(t *testing.T) {
+		var mu sync.Mutex
+		var reqs [][]esTestDoc
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/_bulk", r.URL.Path)
+			assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+			gz, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			b, err := io.ReadAll(gz)
+			require.NoError(t, err)
+			docs := decodeBulk(t, b)
+
+			mu.Lock()
+			reqs = append(reqs, docs)
+			mu.Unlock()
+
+			// reject 'second' temporarily and 'third' permanently
+			var items []string
+			for _, d := range docs {
+				switch d.doc["msg"] {
+				case "second":
+					if len(reqs) == 1 {
+						items = append(items, `{"create":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"busy"}}}`)
+						continue
+					}
+				case "third":
+					items = append(items, `{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}`)
+					continue
+				}
+				items = append(items, `{"create":{"status":201}}`)
+			}
+			_, _ = fmt.Fprintf(w, `{"errors":true,"items":[%s]}`, strings.Join(items, ","))
+		}))
+		defer srv.Close()
+
+		var errs []error
+		wr := NewZapLogger(NewElasticsearchWriter(InfoLevel, NewConfig(
+			WithElasticsearchURL(srv.URL),
+			WithHTTPRetry(3, time.Millisecond),
+			WithHTTPErrorHandler(func(err error) { errs = append(errs, err) }),
+		)))
+		wr.Init(time.Microsecond)
+		wr.Inf("first")
+		wr.Inf("second")
+		wr.Inf("third")
+		wr.Flush(time.Second)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, reqs, 2)
+		require.Len(t, reqs[0], 3)
+		assert.Equal(t, "apilog-"+time.Now().UTC().Format("2006.01.02"), reqs[0][0].index)
+		require.Len(t, reqs[1], 1)
+		assert.Equal(t, "second", reqs[1][0].doc["msg"])
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "failed to send 1 logs: 400 mapper_parsing_exception: failed to parse")
+	})
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should index each log as a document", func(t *testing.T) {
+			srv, ch := newHTTPServer(t, func(int) int { return http.StatusOK })
+			wr := tc.fn(NewElasticsearchWriter(InfoLevel, NewConfig(
+				WithElasticsearchURL(srv.URL),
+				WithElasticsearchIndex("logs-%Y"),
+			)))
+			wr.Init(time.Microsecond)
+			wr.Wrn("warning log", String("key", "val"))
+			wr.Flush(time.Second)
+
+			docs := decodeBulk(t, receiveRequest(t, ch).body)
+			require.Len(t, docs, 1)
+			assert.Equal(t, "logs-"+time.Now().UTC().Format("2006"), docs[0].index)
+			assert.Equal(t, "warning log", docs[0].doc["msg"])
+			assert.Equal(t, "val", docs[0].doc["key"])
+		})
+	}
+}
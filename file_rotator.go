@@ -0,0 +1,492 @@
+package apilog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Compression define how the rotated log files should be compressed.
+type Compression int8
+
+const (
+	NoCompression   Compression = iota // NoCompression keep the rotated log files as is
+	GzipCompression                    // GzipCompression compress the rotated log files using gzip
+	ZstdCompression                    // ZstdCompression compress the rotated log files using zstd
+)
+
+// ext return the file extension of the compressed file.
+func (c Compression) ext() string {
+	switch c {
+	case GzipCompression:
+		return ".gz"
+	case ZstdCompression:
+		return ".zst"
+	}
+	return ""
+}
+
+// newFileRotator return io.WriteCloser that write logs to new file in every
+// period defined by the rotation interval in given cnf, while still rotating
+// by size within the same period. Also take care of the compression,
+// permissions and owner of both the active and rotated log files.
+func newFileRotator(cnf *FileConfig) *fileRotator {
+	base := setupLumberjack(cnf)
+	pattern := cnf.pattern
+	if pattern == "" {
+		pattern = base.Filename
+		if cnf.every > 0 {
+			pattern = defaultFilePattern(base.Filename, cnf.every)
+		}
+	}
+	return &fileRotator{
+		cnf:     *cnf,
+		base:    base,
+		pattern: pattern,
+		match:   matchFilePattern(pattern),
+		now:     time.Now,
+	}
+}
+
+// fileRotator rotate the log file by time in addition to lumberjack size based
+// rotation. Compression and retention by count and age are handled here
+// instead of lumberjack, since lumberjack only knows about the backups of the
+// same file name and only support gzip.
+type fileRotator struct {
+	mu      sync.Mutex
+	cnf     FileConfig
+	base    *lumberjack.Logger
+	pattern string
+	match   *regexp.Regexp // match the base name of the rotated files
+	now     func() time.Time
+
+	lj     *lumberjack.Logger
+	period int64
+	size   int64
+
+	millMu sync.Mutex
+	millWg sync.WaitGroup
+}
+
+// Write implement io.Writer by writing to the file of the current period.
+func (r *fileRotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if start := r.periodStart(now); r.lj == nil || start != r.period {
+		if r.lj != nil {
+			_ = r.lj.Close()
+		}
+		r.period = start
+		r.open(time.Unix(start, 0).In(now.Location()))
+		r.mill()
+	}
+
+	// lumberjack rotate the file before writing if the size would exceed the
+	// maximum size
+	rotated := r.size > 0 && r.size+int64(len(p)) > int64(r.base.MaxSize)*1024*1024
+	n, err := r.lj.Write(p)
+	if rotated {
+		r.size = 0
+		r.ensureFile(r.lj.Filename)
+		r.mill()
+	}
+	r.size += int64(n)
+	return n, err
+}
+
+// Close implement io.Closer by closing the file of the current period, then
+// wait for the rotated log files to be compressed.
+func (r *fileRotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	if r.lj != nil {
+		err = r.lj.Close()
+	}
+	r.millWg.Wait()
+	return err
+}
+
+// Rotate rotate the file of the current period immediately regardless of its
+// size.
+func (r *fileRotator) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lj == nil {
+		return nil
+	}
+	if err := r.lj.Rotate(); err != nil {
+		return err
+	}
+	r.size = 0
+	r.ensureFile(r.lj.Filename)
+	r.mill()
+	return nil
+}
+
+// Reopen close the file of the current period, so the next write reopen the
+// file using the original name.
+func (r *fileRotator) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lj == nil {
+		return nil
+	}
+	err := r.lj.Close()
+	r.ensureFile(r.lj.Filename)
+	r.size = 0
+	if info, sErr := os.Stat(r.lj.Filename); sErr == nil {
+		r.size = info.Size()
+	}
+	return err
+}
+
+// open the file for the period started at t.
+func (r *fileRotator) open(t time.Time) {
+	r.lj = &lumberjack.Logger{
+		Filename:  formatFilePattern(r.pattern, t),
+		MaxSize:   r.base.MaxSize,
+		LocalTime: r.base.LocalTime,
+	}
+	r.ensureFile(r.lj.Filename)
+	r.size = 0
+	if info, err := os.Stat(r.lj.Filename); err == nil {
+		r.size = info.Size()
+	}
+}
+
+// ensureFile make sure given file and its directory exist with the configured
+// permissions and owner. lumberjack keep the permissions and owner of the
+// existing file when rotating.
+func (r *fileRotator) ensureFile(name string) {
+	if dir := filepath.Dir(name); r.cnf.dirMode != 0 {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			_ = os.MkdirAll(dir, r.cnf.dirMode)
+			// not affected by umask
+			_ = os.Chmod(dir, r.cnf.dirMode)
+			r.chown(dir)
+		}
+	}
+	if r.cnf.fileMode != 0 {
+		if f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, r.cnf.fileMode); err == nil {
+			_ = f.Close()
+		}
+		_ = os.Chmod(name, r.cnf.fileMode)
+	}
+	r.chown(name)
+}
+
+// chown change the owner of given name if configured.
+func (r *fileRotator) chown(name string) {
+	if r.cnf.chown {
+		_ = os.Chown(name, r.cnf.uid, r.cnf.gid)
+	}
+}
+
+// periodStart return the start of the period of given t in unix seconds,
+// aligned to the local time, so daily rotation happen at local midnight.
+// Always return 0 if not rotated by time.
+func (r *fileRotator) periodStart(t time.Time) int64 {
+	if r.cnf.every <= 0 {
+		return 0
+	}
+	_, offset := t.Zone()
+	every := int64(r.cnf.every / time.Second)
+	if every <= 0 {
+		every = 1
+	}
+	local := t.Unix() + int64(offset)
+	return local - local%every - int64(offset)
+}
+
+// mill compress then remove old log files in the background. The rotated
+// files are listed before the next rotation, so the active file is never
+// touched.
+func (r *fileRotator) mill() {
+	active, now := r.lj.Filename, r.now()
+	files := r.rotatedFiles(active)
+	r.millWg.Add(1)
+	go func() {
+		defer r.millWg.Done()
+		r.millMu.Lock()
+		defer r.millMu.Unlock()
+
+		r.cleanup(r.compress(files), active, now)
+	}()
+}
+
+// rotatedFiles return every log files produced by the pattern except given
+// active file. The glob may also match other files in the same directory,
+// so only the ones whose name strictly match the pattern are returned.
+func (r *fileRotator) rotatedFiles(active string) []string {
+	matches, err := filepath.Glob(globFilePattern(r.pattern))
+	if err != nil {
+		return nil
+	}
+	files := matches[:0]
+	for _, m := range matches {
+		// glob return cleaned path e.g. 'logs/app.log' for './logs/app.log'
+		if m != filepath.Clean(active) && r.isRotatedFile(filepath.Base(m)) {
+			files = append(files, m)
+		}
+	}
+	return files
+}
+
+// compress given files that are not compressed yet, then return the name of
+// each file after compressed.
+func (r *fileRotator) compress(files []string) []string {
+	ext := r.cnf.compress.ext()
+	if ext == "" {
+		return files
+	}
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if strings.HasSuffix(f, GzipCompression.ext()) || strings.HasSuffix(f, ZstdCompression.ext()) {
+			out = append(out, f)
+			continue
+		}
+		// may already be compressed by the previous mill
+		if _, err := os.Stat(f); os.IsNotExist(err) {
+			out = append(out, f+ext)
+			continue
+		}
+		if err := r.compressFile(f, f+ext); err != nil {
+			out = append(out, f)
+			continue
+		}
+		_ = os.Remove(f)
+		out = append(out, f+ext)
+	}
+	return out
+}
+
+// compressFile compress src to dst, keeping the permissions and owner of src.
+func (r *fileRotator) compressFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	_ = os.Chmod(dst, info.Mode())
+	r.chown(dst)
+
+	var w io.WriteCloser
+	switch r.cnf.compress {
+	case ZstdCompression:
+		if w, err = zstd.NewWriter(out); err != nil {
+			return err
+		}
+	default:
+		w = gzip.NewWriter(out)
+	}
+	if _, err = io.Copy(w, in); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// cleanup remove old log files, including the size based backups, that
+// exceed the maximum number of backups or maximum age. Given active file is
+// never counted as a backup.
+func (r *fileRotator) cleanup(rotated []string, active string, now time.Time) {
+	type oldFile struct {
+		path string
+		mod  time.Time
+	}
+	var files []oldFile
+	for _, m := range rotated {
+		// glob return cleaned path e.g. 'logs/app.log' for './logs/app.log'
+		if filepath.Clean(m) == filepath.Clean(active) {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, oldFile{path: m, mod: info.ModTime()})
+	}
+	// newest first
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	cutoff := now.Add(-time.Duration(r.base.MaxAge) * 24 * time.Hour)
+	for i, f := range files {
+		if (r.base.MaxBackups > 0 && i >= r.base.MaxBackups) || (r.base.MaxAge > 0 && f.mod.Before(cutoff)) {
+			_ = os.Remove(f.path)
+		}
+	}
+}
+
+// defaultFilePattern return file pattern based on given file name and rotation
+// interval e.g. './logs/app.log' with daily rotation become
+// './logs/app-%Y-%m-%d.log'.
+func defaultFilePattern(name string, every time.Duration) string {
+	layout := "%Y-%m-%dT%H-%M"
+	switch {
+	case every%(24*time.Hour) == 0:
+		layout = "%Y-%m-%d"
+	case every%time.Hour == 0:
+		layout = "%Y-%m-%dT%H"
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + layout + ext
+}
+
+// formatFilePattern replace the supported verbs in given pattern with the
+// value from given t. Supported verbs:
+//   - %Y: 4 digits year
+//   - %m: 2 digits month
+//   - %d: 2 digits day of month
+//   - %H: 2 digits hour in 24-hour format
+//   - %M: 2 digits minute
+//   - %%: literal %
+func formatFilePattern(pattern string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			sb.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'm':
+			sb.WriteString(twoDigits(int(t.Month())))
+		case 'd':
+			sb.WriteString(twoDigits(t.Day()))
+		case 'H':
+			sb.WriteString(twoDigits(t.Hour()))
+		case 'M':
+			sb.WriteString(twoDigits(t.Minute()))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(pattern[i])
+		}
+	}
+	return sb.String()
+}
+
+// globFilePattern return glob that match every file produced by given
+// pattern, including lumberjack backups and the compressed ones.
+func globFilePattern(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			sb.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y', 'm', 'd', 'H', 'M':
+			sb.WriteByte('*')
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(pattern[i])
+		}
+	}
+	// lumberjack backups name is '<name>-<timestamp><ext>', followed by the
+	// compression extension if compressed
+	ext := filepath.Ext(sb.String())
+	return strings.TrimSuffix(sb.String(), ext) + "*" + ext + "*"
+}
+
+// backupTimeFormat time format used by lumberjack to name the backups.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// matchFilePattern return regexp that match the base name of every file
+// produced by given pattern, including lumberjack backups and the compressed
+// ones. Each verb only match its fixed-width digits e.g. %Y to 4 digits. The
+// timestamp of the backup if any is captured as the first submatch.
+func matchFilePattern(pattern string) *regexp.Regexp {
+	name := filepath.Base(pattern)
+	ext := filepath.Ext(name)
+	// lumberjack backups name is '<name>-<timestamp><ext>', followed by the
+	// compression extension if compressed
+	return regexp.MustCompile("^" + filePatternRegexp(strings.TrimSuffix(name, ext)) +
+		`(?:-(\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}))?` + filePatternRegexp(ext) +
+		"(?:" + regexp.QuoteMeta(GzipCompression.ext()) + "|" + regexp.QuoteMeta(ZstdCompression.ext()) + ")?$")
+}
+
+// isRotatedFile return true if given base name is produced by the pattern,
+// including lumberjack backups with valid timestamp.
+func (r *fileRotator) isRotatedFile(name string) bool {
+	m := r.match.FindStringSubmatch(name)
+	if m == nil {
+		return false
+	}
+	if m[1] != "" {
+		if _, err := time.Parse(backupTimeFormat, m[1]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// filePatternRegexp return regexp of given pattern by replacing the supported
+// verbs with their fixed-width digits, while quoting the rest.
+func filePatternRegexp(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			sb.WriteString(`\d{4}`)
+		case 'm', 'd', 'H', 'M':
+			sb.WriteString(`\d{2}`)
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteString(regexp.QuoteMeta("%" + pattern[i:i+1]))
+		}
+	}
+	return sb.String()
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
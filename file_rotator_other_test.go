@@ -0,0 +1,11 @@
+//go:build !unix
+
+package apilog
+
+import (
+	"os"
+	"testing"
+)
+
+// assertOwner does nothing, since the owner is only available on unix.
+func assertOwner(*testing.T, os.FileInfo) {}
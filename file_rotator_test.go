@@ -0,0 +1,374 @@
+package apilog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFilePattern(t *testing.T) {
+	tm := time.Date(2026, 10, 7, 9, 5, 0, 0, time.UTC)
+	assert.Equal(t, "app-2026-10-07.log", formatFilePattern("app-%Y-%m-%d.log", tm))
+	assert.Equal(t, "app-2026-10-07T09-05.log", formatFilePattern("app-%Y-%m-%dT%H-%M.log", tm))
+	assert.Equal(t, "app-100%-%x.log", formatFilePattern("app-100%%-%x.log", tm))
+	assert.Equal(t, "app%", formatFilePattern("app%", tm))
+}
+
+func TestGlobFilePattern(t *testing.T) {
+	assert.Equal(t, "logs/app-*-*-**.log*", globFilePattern("logs/app-%Y-%m-%d.log"))
+	assert.Equal(t, "logs/app-100%-%x*.log*", globFilePattern("logs/app-100%%-%x.log"))
+}
+
+func TestMatchFilePattern(t *testing.T) {
+	re := matchFilePattern("logs/app-%Y-%m-%d.log")
+	assert.True(t, re.MatchString("app-2026-10-17.log"))
+	assert.True(t, re.MatchString("app-2026-10-17.log.gz"))
+	assert.False(t, re.MatchString("app-web-api-v2.log"))
+	assert.False(t, re.MatchString("app-26-10-17.log"))
+
+	re = matchFilePattern("logs/app.log")
+	assert.True(t, re.MatchString("app-2026-10-17T09-05-00.000.log"))
+	assert.True(t, re.MatchString("app-2026-10-17T09-05-00.000.log.zst"))
+	assert.False(t, re.MatchString("app-audit.log"))
+	assert.False(t, re.MatchString("app.log.bak"))
+
+	r := &fileRotator{match: re}
+	assert.True(t, r.isRotatedFile("app-2026-10-17T09-05-00.000.log.gz"))
+	assert.False(t, r.isRotatedFile("app-2026-13-45T09-05-00.000.log"))
+}
+
+func TestDefaultFilePattern(t *testing.T) {
+	assert.Equal(t, "./logs/app-%Y-%m-%d.log", defaultFilePattern("./logs/app.log", 24*time.Hour))
+	assert.Equal(t, "./logs/app-%Y-%m-%dT%H.log", defaultFilePattern("./logs/app.log", time.Hour))
+	assert.Equal(t, "./logs/app-%Y-%m-%dT%H-%M.log", defaultFilePattern("./logs/app.log", 15*time.Minute))
+}
+
+func TestPeriodStart(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*60*60)
+	r := &fileRotator{cnf: FileConfig{every: 24 * time.Hour}}
+	// should be aligned to the local midnight instead of UTC
+	tm := time.Date(2026, 10, 17, 1, 30, 0, 0, loc)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, loc).Unix(), r.periodStart(tm))
+
+	r.cnf.every = time.Hour
+	assert.Equal(t, time.Date(2026, 10, 17, 1, 0, 0, 0, loc).Unix(), r.periodStart(tm))
+}
+
+func TestFileRotator(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+	r := newFileRotator(&FileConfig{
+		path:  filepath.Join(dir, "app.log"),
+		every: 24 * time.Hour,
+		num:   2,
+		age:   7,
+	})
+	r.now = func() time.Time { return now }
+
+	t.Run("Should write to the file of the current period", func(t *testing.T) {
+		_, err := r.Write([]byte("first\n"))
+		require.NoError(t, err)
+		b, err := os.ReadFile(filepath.Join(dir, "app-2026-10-17.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "first\n", string(b))
+	})
+
+	t.Run("Should rotate to new file in the next period", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		_, err := r.Write([]byte("second\n"))
+		require.NoError(t, err)
+		b, err := os.ReadFile(filepath.Join(dir, "app-2026-10-18.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "second\n", string(b))
+		assert.FileExists(t, filepath.Join(dir, "app-2026-10-17.log"))
+	})
+
+	t.Run("Should only retain the maximum number of backups", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			now = now.Add(24 * time.Hour)
+			_, err := r.Write([]byte("next\n"))
+			require.NoError(t, err)
+			// make sure each file has different modification time
+			mod := now.Add(-time.Duration(3-i) * time.Second)
+			require.NoError(t, os.Chtimes(r.lj.Filename, mod, mod))
+		}
+		r.millWg.Wait()
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+		assert.Len(t, matches, 3) // active file + 2 backups
+		assert.NoFileExists(t, filepath.Join(dir, "app-2026-10-17.log"))
+		assert.FileExists(t, filepath.Join(dir, "app-2026-10-21.log"))
+	})
+
+	t.Run("Should remove backups older than the maximum age", func(t *testing.T) {
+		old := now.Add(-10 * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "app-2026-10-20.log"), old, old))
+		now = now.Add(24 * time.Hour)
+		_, err := r.Write([]byte("next\n"))
+		require.NoError(t, err)
+		r.millWg.Wait()
+		assert.NoFileExists(t, filepath.Join(dir, "app-2026-10-20.log"))
+		assert.NoError(t, r.Close())
+	})
+}
+
+// chdir change the working directory to given dir until the test is done.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestFileRotatorSibling(t *testing.T) {
+	t.Run("Should not remove other log file that does not match the pattern", func(t *testing.T) {
+		dir := t.TempDir()
+		sibling := filepath.Join(dir, "app-web-api-v2.log")
+		require.NoError(t, os.WriteFile(sibling, []byte("other\n"), 0644))
+		old := time.Now().Add(-30 * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(sibling, old, old))
+
+		now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+		r := newFileRotator(&FileConfig{
+			path:  filepath.Join(dir, "app.log"),
+			every: 24 * time.Hour,
+			num:   1,
+			age:   7,
+		})
+		r.now = func() time.Time { return now }
+		for i := 0; i < 3; i++ {
+			_, err := r.Write([]byte("next\n"))
+			require.NoError(t, err)
+			now = now.Add(24 * time.Hour)
+		}
+		require.NoError(t, r.Close())
+
+		b, err := os.ReadFile(sibling)
+		require.NoError(t, err)
+		assert.Equal(t, "other\n", string(b))
+		assert.NoFileExists(t, filepath.Join(dir, "app-2026-10-17.log"))
+	})
+}
+
+func TestFileRotatorSiblingRotate(t *testing.T) {
+	t.Run("Should not compress other log file after rotated", func(t *testing.T) {
+		dir := t.TempDir()
+		sibling := filepath.Join(dir, "app-audit.log")
+		require.NoError(t, os.WriteFile(sibling, []byte("audit\n"), 0644))
+		r := newFileRotator(&FileConfig{
+			path:     filepath.Join(dir, "app.log"),
+			compress: GzipCompression,
+		})
+
+		_, err := r.Write([]byte("first\n"))
+		require.NoError(t, err)
+		require.NoError(t, r.Rotate())
+		require.NoError(t, r.Close())
+
+		b, err := os.ReadFile(sibling)
+		require.NoError(t, err)
+		assert.Equal(t, "audit\n", string(b))
+		assert.NoFileExists(t, sibling+".gz")
+		matches, err := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+		require.NoError(t, err)
+		assert.Len(t, matches, 1)
+	})
+}
+
+func TestFileRotatorRelativePath(t *testing.T) {
+	t.Run("Should not count the active file as a backup", func(t *testing.T) {
+		chdir(t, t.TempDir())
+		require.NoError(t, os.Mkdir("logs", 0755))
+		now := time.Now()
+		// newest first
+		for i, name := range []string{"app.log", "app-3.log", "app-2.log", "app-1.log"} {
+			path := filepath.Join("logs", name)
+			require.NoError(t, os.WriteFile(path, []byte("log\n"), 0644))
+			mod := now.Add(-time.Duration(i) * time.Hour)
+			require.NoError(t, os.Chtimes(path, mod, mod))
+		}
+
+		r := newFileRotator(&FileConfig{path: "./logs/app.log", num: 2})
+		matches, err := filepath.Glob(filepath.Join("logs", "*.log"))
+		require.NoError(t, err)
+		r.cleanup(matches, "./logs/app.log", now)
+
+		assert.FileExists(t, filepath.Join("logs", "app.log"))
+		assert.FileExists(t, filepath.Join("logs", "app-3.log"))
+		assert.FileExists(t, filepath.Join("logs", "app-2.log"))
+		assert.NoFileExists(t, filepath.Join("logs", "app-1.log"))
+	})
+
+	t.Run("Should compress the rotated file and keep the active one as is", func(t *testing.T) {
+		chdir(t, t.TempDir())
+		now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+		r := newFileRotator(&FileConfig{
+			path:     "./logs/app.log",
+			every:    24 * time.Hour,
+			compress: GzipCompression,
+			// create the active file before listing the rotated files
+			fileMode: 0644,
+		})
+		r.now = func() time.Time { return now }
+
+		_, err := r.Write([]byte("first\n"))
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = r.Write([]byte("second\n"))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		assert.Equal(t, "first\n", decompress(t, filepath.Join("logs", "app-2026-10-17.log.gz")))
+		b, err := os.ReadFile(filepath.Join("logs", "app-2026-10-18.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "second\n", string(b))
+		assert.NoFileExists(t, filepath.Join("logs", "app-2026-10-18.log.gz"))
+	})
+}
+
+func TestNewFileWriterWithRotation(t *testing.T) {
+	dir := t.TempDir()
+	cnf := NewConfig(
+		WithFilePath(filepath.Join(dir, "app.log")),
+		WithFileRotation(time.Hour),
+		WithFilePattern(filepath.Join(dir, "app-%Y%m%d%H.log")),
+	)
+	wr := NewFileWriter(InfoLevel, cnf)
+	assert.IsType(t, &fileRotator{}, wr.Writer())
+
+	_, err := wr.Writer().Write([]byte("hello\n"))
+	require.NoError(t, err)
+	wr.Flush(-1)
+	assert.FileExists(t, filepath.Join(dir, time.Now().Format("app-2006010215.log")))
+}
+
+// decompress return the decompressed content of given file.
+func decompress(t *testing.T, name string) string {
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var rd io.Reader
+	switch filepath.Ext(name) {
+	case ".gz":
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		rd = gz
+	case ".zst":
+		zr, err := zstd.NewReader(f)
+		require.NoError(t, err)
+		defer zr.Close()
+		rd = zr
+	}
+	b, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestFileRotatorCompression(t *testing.T) {
+	testCases := []struct {
+		name     string
+		compress Compression
+		ext      string
+	}{
+		{name: "Gzip", compress: GzipCompression, ext: ".gz"},
+		{name: "Zstd", compress: ZstdCompression, ext: ".zst"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should compress the rotated file by time and keep the active one as is", func(t *testing.T) {
+			dir := t.TempDir()
+			now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+			r := newFileRotator(&FileConfig{
+				path:     filepath.Join(dir, "app.log"),
+				every:    24 * time.Hour,
+				compress: tc.compress,
+			})
+			r.now = func() time.Time { return now }
+
+			_, err := r.Write([]byte("first\n"))
+			require.NoError(t, err)
+			now = now.Add(time.Hour)
+			_, err = r.Write([]byte("second\n"))
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+
+			assert.NoFileExists(t, filepath.Join(dir, "app-2026-10-17.log"))
+			assert.Equal(t, "first\n", decompress(t, filepath.Join(dir, "app-2026-10-17.log"+tc.ext)))
+			b, err := os.ReadFile(filepath.Join(dir, "app-2026-10-18.log"))
+			require.NoError(t, err)
+			assert.Equal(t, "second\n", string(b))
+		})
+	}
+
+	t.Run("Should compress the rotated file by size", func(t *testing.T) {
+		dir := t.TempDir()
+		r := newFileRotator(&FileConfig{
+			path:     filepath.Join(dir, "app.log"),
+			size:     1,
+			compress: GzipCompression,
+		})
+
+		chunk := bytes.Repeat([]byte("a"), 512*1024)
+		for i := 0; i < 3; i++ {
+			_, err := r.Write(chunk)
+			require.NoError(t, err)
+		}
+		require.NoError(t, r.Close())
+
+		matches, err := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, 2*len(chunk), len(decompress(t, matches[0])))
+		info, err := os.Stat(filepath.Join(dir, "app.log"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(chunk)), info.Size())
+	})
+}
+
+func TestFileRotatorPermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "logs")
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.Local)
+	r := newFileRotator(&FileConfig{
+		path:     filepath.Join(dir, "app.log"),
+		every:    24 * time.Hour,
+		compress: GzipCompression,
+		fileMode: 0640,
+		dirMode:  0750,
+		chown:    true,
+		uid:      os.Getuid(),
+		gid:      os.Getgid(),
+	})
+	r.now = func() time.Time { return now }
+
+	_, err := r.Write([]byte("first\n"))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = r.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	testCases := []struct {
+		name string
+		path string
+		mode os.FileMode
+	}{
+		{name: "directory", path: dir, mode: 0750 | os.ModeDir},
+		{name: "active file", path: filepath.Join(dir, "app-2026-10-18.log"), mode: 0640},
+		{name: "rotated file", path: filepath.Join(dir, "app-2026-10-17.log.gz"), mode: 0640},
+	}
+	for _, tc := range testCases {
+		t.Run("Should apply the configured permissions and owner to the "+tc.name, func(t *testing.T) {
+			info, err := os.Stat(tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.mode, info.Mode())
+			assertOwner(t, info)
+		})
+	}
+}
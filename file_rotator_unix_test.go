@@ -0,0 +1,19 @@
+//go:build unix
+
+package apilog
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertOwner assert given file is owned by the current user and group.
+func assertOwner(t *testing.T, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		assert.Equal(t, os.Getuid(), int(st.Uid))
+		assert.Equal(t, os.Getgid(), int(st.Gid))
+	}
+}
@@ -14,19 +14,52 @@ func NewFileWriter(lvl Level, cnf *Config) Writer {
 		cnf = &Config{}
 	}
 
-	return &fileOutputWithLumberjack{lvl: lvl, wr: setupLumberjack(&cnf.file)}
+	var wr rotateWriteCloser = setupLumberjack(&cnf.file)
+	if cnf.file.rotator() {
+		wr = newFileRotator(&cnf.file)
+	}
+	return &fileOutputWithLumberjack{lvl: NewAtomicLevel(lvl), wr: wr}
+}
+
+// rotateWriteCloser io.WriteCloser that can be rotated on demand, implemented
+// by both lumberjack.Logger and fileRotator.
+type rotateWriteCloser interface {
+	io.WriteCloser
+	Rotate() error
 }
 
 type fileOutputWithLumberjack struct {
-	wr  *lumberjack.Logger
-	lvl Level
+	wr  rotateWriteCloser
+	lvl *AtomicLevel
 }
 
-func (f *fileOutputWithLumberjack) Writer() io.Writer     { return f.wr }
-func (f *fileOutputWithLumberjack) Output() Output        { return FILE }
-func (f *fileOutputWithLumberjack) Level() Level          { return f.lvl }
-func (f *fileOutputWithLumberjack) Wait(_ time.Duration)  {}
-func (f *fileOutputWithLumberjack) Flush(_ time.Duration) { f.wr.Close() }
+func (f *fileOutputWithLumberjack) Writer() io.Writer         { return f.wr }
+func (f *fileOutputWithLumberjack) Output() Output            { return FILE }
+func (f *fileOutputWithLumberjack) Level() Level              { return f.lvl.Level() }
+func (f *fileOutputWithLumberjack) AtomicLevel() *AtomicLevel { return f.lvl }
+func (f *fileOutputWithLumberjack) Wait(_ time.Duration)      {}
+func (f *fileOutputWithLumberjack) Flush(_ time.Duration)     { f.wr.Close() }
+
+// Rotate implement Reopener by rotating the file immediately regardless of
+// its size.
+func (f *fileOutputWithLumberjack) Rotate() error { return f.wr.Rotate() }
+
+// Reopen implement Reopener by closing the file, so the next log reopen the
+// file using the original name. Logs written concurrently are never dropped,
+// they are written either to the old or the new file.
+func (f *fileOutputWithLumberjack) Reopen() error {
+	if r, ok := f.wr.(interface{ Reopen() error }); ok {
+		return r.Reopen()
+	}
+	// lumberjack reopen the file on the next write
+	return f.wr.Close()
+}
+
+// rotator return true if any feature that lumberjack does not support is
+// configured, so fileRotator should be used instead.
+func (f *FileConfig) rotator() bool {
+	return f.every > 0 || f.compress != NoCompression || f.fileMode != 0 || f.dirMode != 0 || f.chown
+}
 
 // setupLumberjack init and set default value to lumberjack.Logger if no value
 // provided in given config.
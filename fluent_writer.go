@@ -0,0 +1,225 @@
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// fluentTimeout maximum time to connect, write a batch and read its ack.
+const fluentTimeout = 10 * time.Second
+
+// NewFluentWriter return Writer implementer that forward logs in batches to
+// fluentd or fluent bit using the forward protocol by given Config and set
+// given lvl as the log Level. Each batch is sent as a single PackedForward
+// message, optionally waiting for the ack of each chunk. Connect lazily and
+// reconnect with exponential backoff on failure, so the collector does not
+// have to be available yet.
+func NewFluentWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	f := &fluentOutput{lvl: NewAtomicLevel(lvl), cnf: cnf.fluent}
+	// set default value
+	if f.cnf.network == "" {
+		f.cnf.network = "tcp"
+	}
+	if f.cnf.addr == "" {
+		f.cnf.addr = "localhost:24224"
+	}
+	if f.cnf.tag == "" {
+		f.cnf.tag = "apilog"
+	}
+	if f.cnf.count <= 0 {
+		f.cnf.count = 500
+	}
+	if f.cnf.interval <= 0 {
+		f.cnf.interval = time.Second
+	}
+	if f.cnf.retry == 0 {
+		f.cnf.retry = 5
+	}
+	if f.cnf.backoff <= 0 {
+		f.cnf.backoff = 500 * time.Millisecond
+	}
+	f.batcher = newBatcher(f.cnf.count, 1<<20, f.cnf.interval, f.send, f.cnf.onError)
+	return f
+}
+
+type fluentOutput struct {
+	*batcher
+	lvl *AtomicLevel
+	cnf FluentConfig
+
+	mu   sync.Mutex // guard conn and r
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// send encode given entries as PackedForward message then forward it,
+// reconnecting with exponential backoff until the maximum number of retries
+// is reached or ctx is done.
+func (f *fluentOutput) send(ctx context.Context, entries [][]byte) error {
+	msg, chunk, err := f.encode(entries)
+	if err != nil {
+		return f.fail(len(entries), err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = f.forward(ctx, msg, chunk); err == nil {
+			return nil
+		}
+		if attempt >= max(f.cnf.retry, 0) || ctx.Err() != nil {
+			return f.fail(len(entries), err)
+		}
+
+		t := time.NewTimer(backoffOf(f.cnf.backoff, attempt))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return f.fail(len(entries), ctx.Err())
+		}
+	}
+}
+
+// encode return given JSON encoded logs as PackedForward message
+// [tag, entries, option] along with its chunk id if ack is enabled. Each
+// entry is [EventTime, record] using the time field of the log if any.
+func (f *fluentOutput) encode(entries [][]byte) ([]byte, string, error) {
+	var stream []byte
+	for _, e := range entries {
+		var record map[string]any
+		dec := json.NewDecoder(bytes.NewReader(e))
+		dec.UseNumber()
+		if err := dec.Decode(&record); err != nil || record == nil {
+			// forward it as is
+			record = map[string]any{"message": string(e)}
+		}
+		stream = append(stream, 0x92)
+		stream = appendMsgpack(stream, timeOf(record))
+		stream = appendMsgpack(stream, record)
+	}
+
+	option := map[string]any{"size": len(entries)}
+	var chunk string
+	if f.cnf.ack {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, "", err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+
+	msg := []byte{0x93}
+	msg = appendMsgpack(msg, f.cnf.tag)
+	msg = appendMsgpack(msg, stream)
+	msg = appendMsgpack(msg, option)
+	return msg, chunk, nil
+}
+
+// forward write given msg once, connecting first if not connected yet, then
+// wait for the ack of given chunk if not empty. Close the connection on
+// failure, so it reconnects on the next attempt.
+func (f *fluentOutput) forward(ctx context.Context, msg []byte, chunk string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil {
+		if err := f.dial(ctx, fluentTimeout); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(fluentTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn := f.conn
+	_ = conn.SetDeadline(deadline)
+	// unblock the connection as soon as ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	err := f.write(msg, chunk)
+	if err != nil {
+		f.close()
+	}
+	return err
+}
+
+// write write given msg to the current connection then wait for the ack of
+// given chunk if not empty.
+func (f *fluentOutput) write(msg []byte, chunk string) error {
+	if _, err := f.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	resp, err := readMsgpack(f.r)
+	if err != nil {
+		return fmt.Errorf("failed to read ack: %w", err)
+	}
+	m, _ := resp.(map[string]any)
+	if ack, _ := m["ack"].(string); ack != chunk {
+		return errors.New("unexpected ack")
+	}
+	return nil
+}
+
+// dial connect to the collector within given timeout.
+func (f *fluentOutput) dial(ctx context.Context, timeout time.Duration) error {
+	d := &net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, f.cnf.network, f.cnf.addr)
+	if err != nil {
+		return err
+	}
+	f.conn, f.r = conn, bufio.NewReader(conn)
+	return nil
+}
+
+// close close the current connection if any.
+func (f *fluentOutput) close() {
+	if f.conn != nil {
+		_ = f.conn.Close()
+		f.conn, f.r = nil, nil
+	}
+}
+
+func (f *fluentOutput) Writer() io.Writer         { return f }
+func (f *fluentOutput) Output() Output            { return FLUENT }
+func (f *fluentOutput) Level() Level              { return f.lvl.Level() }
+func (f *fluentOutput) AtomicLevel() *AtomicLevel { return f.lvl }
+
+// Wait try to connect to the collector within given dur.
+func (f *fluentOutput) Wait(dur time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil && dur > 0 {
+		_ = f.dial(context.Background(), dur)
+	}
+}
+
+// Flush send the remaining batch within given dur then close the connection
+// to the collector.
+func (f *fluentOutput) Flush(dur time.Duration) {
+	f.batcher.Flush(dur)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.close()
+}
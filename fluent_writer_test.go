@@ -0,0 +1,254 @@
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fluentMessage PackedForward message received by fluentServer.
+type fluentMessage struct {
+	tag     string
+	times   []time.Time
+	records []map[string]any
+	option  map[string]any
+}
+
+// fluentServer accept connections from given ln then decode each received
+// PackedForward message. The connection is closed without ack instead for
+// the first given drop messages. Ack is sent if the message has chunk.
+func fluentServer(t *testing.T, ln net.Listener, drop int32) <-chan fluentMessage {
+	ch := make(chan fluentMessage, 100)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					v, err := readMsgpack(rd)
+					if err != nil {
+						return
+					}
+					if atomic.AddInt32(&drop, -1) >= 0 {
+						return
+					}
+					msg := decodeFluentMessage(t, v)
+					if chunk, ok := msg.option["chunk"]; ok {
+						_, _ = conn.Write(appendMsgpack(nil, map[string]any{"ack": chunk}))
+					}
+					ch <- msg
+				}
+			}()
+		}
+	}()
+	return ch
+}
+
+// decodeFluentMessage decode given PackedForward message.
+func decodeFluentMessage(t *testing.T, v any) fluentMessage {
+	arr, ok := v.([]any)
+	require.True(t, ok)
+	require.Len(t, arr, 3)
+
+	msg := fluentMessage{tag: arr[0].(string), option: arr[2].(map[string]any)}
+	rd := bytes.NewReader(arr[1].([]byte))
+	for rd.Len() > 0 {
+		e, err := readMsgpack(rd)
+		require.NoError(t, err)
+		entry := e.([]any)
+		require.Len(t, entry, 2)
+		msg.times = append(msg.times, entry[0].(time.Time))
+		msg.records = append(msg.records, entry[1].(map[string]any))
+	}
+	return msg
+}
+
+// receiveFluent return the next received message or fail after a second.
+func receiveFluent(t *testing.T, ch <-chan fluentMessage) fluentMessage {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return fluentMessage{}
+}
+
+func TestNewFluentWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewFluentWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &fluentOutput{}, wr.Writer())
+		assert.Equal(t, FLUENT, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		f := wr.(*fluentOutput)
+		assert.Equal(t, "tcp", f.cnf.network)
+		assert.Equal(t, "localhost:24224", f.cnf.addr)
+		assert.Equal(t, "apilog", f.cnf.tag)
+		assert.Equal(t, 500, f.cnf.count)
+		assert.Equal(t, time.Second, f.cnf.interval)
+		assert.Equal(t, 5, f.cnf.retry)
+		assert.Equal(t, 500*time.Millisecond, f.cnf.backoff)
+	})
+}
+
+func TestFluentWriter(t *testing.T) {
+	t.Run("Should forward each batch as PackedForward message using the log time", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ch := fluentServer(t, ln, 0)
+
+		wr := NewFluentWriter(InfoLevel, NewConfig(
+			WithFluentAddress(ln.Addr().String()),
+			WithFluentTag("app.api"),
+			WithFluentBatchSize(2),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"level":"INFO","time":"2024-08-28T07:59:13.259+07:00","msg":"first","code":200,"req":{"id":"1"}}` + "\n"))
+		_, _ = wr.Writer().Write([]byte(`not a json`))
+
+		msg := receiveFluent(t, ch)
+		assert.Equal(t, "app.api", msg.tag)
+		assert.Equal(t, map[string]any{"size": int64(2)}, msg.option)
+		require.Len(t, msg.records, 2)
+		assert.Equal(t, time.Date(2024, 8, 28, 0, 59, 13, 259000000, time.UTC), msg.times[0].UTC())
+		assert.Equal(t, map[string]any{
+			"level": "INFO",
+			"time":  "2024-08-28T07:59:13.259+07:00",
+			"msg":   "first",
+			"code":  int64(200),
+			"req":   map[string]any{"id": "1"},
+		}, msg.records[0])
+		assert.Equal(t, map[string]any{"message": "not a json"}, msg.records[1])
+		assert.WithinDuration(t, time.Now(), msg.times[1], time.Minute)
+		wr.Flush(time.Second)
+	})
+
+	t.Run("Given unix socket should forward the remaining batch on flush", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "fluent")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		sock := filepath.Join(dir, "fluent.sock")
+		ln, err := net.Listen("unix", sock)
+		require.NoError(t, err)
+		ch := fluentServer(t, ln, 0)
+
+		wr := NewFluentWriter(InfoLevel, NewConfig(WithFluentNetwork("unix"), WithFluentAddress(sock)))
+		wr.Wait(time.Second)
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		msg := receiveFluent(t, ch)
+		require.Len(t, msg.records, 1)
+		assert.Equal(t, "hello", msg.records[0]["msg"])
+	})
+
+	t.Run("Given ack enabled should resend the batch that is not acknowledged after reconnecting", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ch := fluentServer(t, ln, 2)
+
+		var failed atomic.Int32
+		wr := NewFluentWriter(InfoLevel, NewConfig(
+			WithFluentAddress(ln.Addr().String()),
+			WithFluentAck(true),
+			WithFluentRetry(3, time.Millisecond),
+			WithFluentErrorHandler(func(error) { failed.Add(1) }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		msg := receiveFluent(t, ch)
+		assert.Equal(t, int64(1), msg.option["size"])
+		assert.NotEmpty(t, msg.option["chunk"])
+		assert.Equal(t, "hello", msg.records[0]["msg"])
+		assert.Zero(t, failed.Load())
+	})
+
+	t.Run("Given collector that is not available yet should reconnect with backoff", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		wr := NewFluentWriter(InfoLevel, NewConfig(
+			WithFluentAddress(addr),
+			WithFluentBatchInterval(10*time.Millisecond),
+			WithFluentRetry(10, 20*time.Millisecond),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+
+		time.Sleep(50 * time.Millisecond)
+		ln, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		ch := fluentServer(t, ln, 0)
+
+		msg := receiveFluent(t, ch)
+		assert.Equal(t, "hello", msg.records[0]["msg"])
+		wr.Flush(time.Second)
+	})
+
+	t.Run("Given unreachable collector should report the error after all retries", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		var reported error
+		wr := NewFluentWriter(InfoLevel, NewConfig(
+			WithFluentAddress(addr),
+			WithFluentRetry(-1, 0),
+			WithFluentErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		var opErr *net.OpError
+		assert.True(t, errors.As(reported, &opErr))
+		assert.ErrorContains(t, reported, "failed to send 1 logs")
+	})
+}
+
+func TestFluentWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should forward each log as record", func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			ch := fluentServer(t, ln, 0)
+
+			wr := tc.fn(NewFluentWriter(InfoLevel, NewConfig(WithFluentAddress(ln.Addr().String()))))
+			wr.Init(time.Second)
+			wr.Dbg("debug log")
+			wr.Inf("info log", String("key", "val"))
+			wr.Flush(time.Second)
+
+			msg := receiveFluent(t, ch)
+			require.Len(t, msg.records, 1)
+			assert.Equal(t, "info log", msg.records[0]["msg"])
+			assert.Equal(t, "val", msg.records[0]["key"])
+			assert.Equal(t, "INFO", msg.records[0]["level"])
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package apilog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GELFCompression define how each GELF message is compressed when sent over
+// udp. Not used by tcp, since graylog does not support compressed message
+// over tcp.
+type GELFCompression int8
+
+const (
+	GELFGzip         GELFCompression = iota // GELFGzip compress each message using gzip
+	GELFZlib                                // GELFZlib compress each message using zlib
+	GELFUncompressed                        // GELFUncompressed send each message as is
+)
+
+const (
+	// gelfChunkHeader size of the header of each GELF chunk.
+	gelfChunkHeader = 12
+	// gelfMaxChunks maximum number of chunks of a single GELF message.
+	gelfMaxChunks = 128
+)
+
+// NewGELFWriter return Writer implementer that send logs to graylog using
+// GELF 1.1 format by given Config and set given lvl as the log Level. Message
+// that is larger than the chunk size is chunked when sent over udp, while
+// each message is terminated by null byte when sent over tcp. Connect lazily
+// and reconnect once on each failed write, so graylog does not have to be
+// available yet. Write fail fast without dialing while waiting for the backoff
+// of the last failed dial.
+func NewGELFWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	g := &gelfOutput{lvl: NewAtomicLevel(lvl), cnf: cnf.gelf, timeout: writeTimeout}
+	// set default value
+	if g.cnf.network == "" {
+		g.cnf.network = "udp"
+	}
+	if g.cnf.addr == "" {
+		g.cnf.addr = "localhost:12201"
+	}
+	if g.cnf.host == "" {
+		g.cnf.host, _ = os.Hostname()
+	}
+	if g.cnf.chunk <= gelfChunkHeader {
+		g.cnf.chunk = 1420
+	}
+	return g
+}
+
+type gelfOutput struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	redial  redialer
+	timeout time.Duration // maximum time to write each message
+	cnf     GELFConfig
+	lvl     *AtomicLevel
+}
+
+// Write implement io.Writer by sending given JSON encoded log as GELF
+// message.
+func (g *gelfOutput) Write(p []byte) (int, error) {
+	msg, err := g.format(bytes.TrimSpace(p), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	packets, err := g.packets(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		if err = g.redial.dial(g.dial); err != nil {
+			return 0, err
+		}
+	}
+	if err = g.send(packets); err != nil {
+		// reconnect then retry once
+		_ = g.conn.Close()
+		g.conn = nil
+		if err = g.redial.dial(g.dial); err != nil {
+			return 0, err
+		}
+		if err = g.send(packets); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// send write given packets to the current connection within the write
+// timeout, so graylog that stops reading does not block the caller forever.
+func (g *gelfOutput) send(packets [][]byte) error {
+	_ = g.conn.SetWriteDeadline(time.Now().Add(g.timeout))
+	for _, b := range packets {
+		if _, err := g.conn.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// format return given JSON encoded log as GELF message. The message become
+// the short_message, the stack trace if any become the full_message, and
+// every other field become additional field prefixed by underscore, nested
+// fields e.g. from Group are flattened using dot separated key. The log is
+// used as the short_message as is if it's not a JSON object, and given t is
+// used if it has no time.
+func (g *gelfOutput) format(p []byte, t time.Time) ([]byte, error) {
+	msg := map[string]any{
+		"version": "1.1",
+		"host":    g.cnf.host,
+		"level":   toSyslogSeverity(levelOf(p)),
+	}
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		msg["short_message"] = string(p)
+		msg["timestamp"] = unixSeconds(t)
+		return json.Marshal(msg)
+	}
+
+	if _, ok := fields["time"]; ok {
+		t = timeOf(fields)
+	}
+	msg["timestamp"] = unixSeconds(t)
+	msg["short_message"] = "-" // required to be non-empty
+	if s, ok := fields["msg"].(string); ok && s != "" {
+		msg["short_message"] = s
+	}
+	if s, ok := fields["stacktrace"].(string); ok {
+		msg["full_message"] = s
+	}
+	for _, k := range []string{"level", "time", "msg", "stacktrace"} {
+		delete(fields, k)
+	}
+	gelfFields("", fields, msg)
+	return json.Marshal(msg)
+}
+
+// gelfFields add given fields to given msg as additional fields. Nested
+// object is flattened using dot separated key prefixed by given prefix.
+// Since additional field may only be a string or number, other value is
+// encoded as JSON string and null is skipped.
+func gelfFields(prefix string, fields map[string]any, msg map[string]any) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case nil:
+		case map[string]any:
+			gelfFields(prefix+k+".", v, msg)
+		case string, json.Number:
+			msg[gelfFieldName(prefix+k)] = v
+		default:
+			b, _ := json.Marshal(v)
+			msg[gelfFieldName(prefix+k)] = string(b)
+		}
+	}
+}
+
+// gelfFieldName return given key as additional field name by prefixing it
+// with underscore and replacing characters that are not allowed with
+// underscore. The id field is reserved by graylog, so it's renamed to id_.
+func gelfFieldName(k string) string {
+	if k == "id" {
+		k = "id_"
+	}
+	return "_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, k)
+}
+
+// unixSeconds return given t as seconds since epoch with milliseconds.
+func unixSeconds(t time.Time) json.Number {
+	return json.Number(fmt.Sprintf("%d.%03d", t.Unix(), t.Nanosecond()/int(time.Millisecond)))
+}
+
+// packets return given GELF message as packets ready to be written based on
+// the network. Over tcp it's terminated by null byte, while over udp it's
+// compressed then chunked if larger than the chunk size.
+func (g *gelfOutput) packets(msg []byte) ([][]byte, error) {
+	if g.stream() {
+		return [][]byte{append(msg, 0)}, nil
+	}
+
+	b, err := g.compress(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) <= g.cnf.chunk {
+		return [][]byte{b}, nil
+	}
+
+	size := g.cnf.chunk - gelfChunkHeader
+	n := (len(b) + size - 1) / size
+	if n > gelfMaxChunks {
+		return nil, fmt.Errorf("message of %d bytes need %d chunks, exceeding the maximum of %d", len(b), n, gelfMaxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		c := make([]byte, 0, g.cnf.chunk)
+		c = append(c, 0x1e, 0x0f)
+		c = append(c, id...)
+		c = append(c, byte(i), byte(n))
+		c = append(c, b[i*size:min((i+1)*size, len(b))]...)
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
+
+// compress return given msg compressed based on the compression config.
+func (g *gelfOutput) compress(msg []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var wr io.WriteCloser
+	switch g.cnf.compress {
+	case GELFUncompressed:
+		return msg, nil
+	case GELFZlib:
+		wr = zlib.NewWriter(&buf)
+	default:
+		wr = gzip.NewWriter(&buf)
+	}
+	if _, err := wr.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := wr.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// stream return true if the configured network is stream based.
+func (g *gelfOutput) stream() bool {
+	switch g.cnf.network {
+	case "udp", "udp4", "udp6":
+		return false
+	}
+	return true
+}
+
+// dial connect to graylog within given timeout. No timeout if zero.
+func (g *gelfOutput) dial(timeout time.Duration) error {
+	d := &net.Dialer{Timeout: timeout}
+	var err error
+	if g.conn, err = d.Dial(g.cnf.network, g.cnf.addr); err != nil {
+		g.conn = nil
+	}
+	return err
+}
+
+func (g *gelfOutput) Writer() io.Writer         { return g }
+func (g *gelfOutput) Output() Output            { return GELF }
+func (g *gelfOutput) Level() Level              { return g.lvl.Level() }
+func (g *gelfOutput) AtomicLevel() *AtomicLevel { return g.lvl }
+
+// Wait try to connect to graylog within given dur.
+func (g *gelfOutput) Wait(dur time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil && dur > 0 {
+		_ = g.dial(dur)
+	}
+}
+
+// Flush close the connection to graylog.
+func (g *gelfOutput) Flush(_ time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn != nil {
+		_ = g.conn.Close()
+		g.conn = nil
+	}
+}
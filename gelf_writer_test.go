@@ -0,0 +1,338 @@
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gelfPacketServer decode each GELF message received by given conn, joining
+// the chunks if chunked.
+func gelfPacketServer(t *testing.T, conn net.PacketConn) <-chan map[string]any {
+	ch := make(chan map[string]any, 100)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		chunks := make(map[string][][]byte)
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			b := bytes.Clone(buf[:n])
+			if b[0] == 0x1e && b[1] == 0x0f {
+				id := string(b[2:10])
+				if chunks[id] == nil {
+					chunks[id] = make([][]byte, b[11])
+				}
+				chunks[id][b[10]] = b[gelfChunkHeader:]
+				for _, c := range chunks[id] {
+					if c == nil {
+						b = nil
+						break
+					}
+				}
+				if b == nil {
+					continue
+				}
+				b = bytes.Join(chunks[id], nil)
+				delete(chunks, id)
+			}
+			ch <- decodeGELF(t, b)
+		}
+	}()
+	return ch
+}
+
+// decodeGELF decompress if compressed then decode given GELF message.
+func decodeGELF(t *testing.T, b []byte) map[string]any {
+	var rd io.Reader = bytes.NewReader(b)
+	switch {
+	case b[0] == 0x1f && b[1] == 0x8b:
+		gz, err := gzip.NewReader(rd)
+		require.NoError(t, err)
+		rd = gz
+	case b[0] == 0x78:
+		zr, err := zlib.NewReader(rd)
+		require.NoError(t, err)
+		rd = zr
+	}
+	var msg map[string]any
+	dec := json.NewDecoder(rd)
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&msg))
+	return msg
+}
+
+// receiveGELF return the next received message or fail after a second.
+func receiveGELF(t *testing.T, ch <-chan map[string]any) map[string]any {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+func TestNewGELFWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewGELFWriter(WarnLevel, nil)
+		assert.IsType(t, &gelfOutput{}, wr.Writer())
+		assert.Equal(t, GELF, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		g := wr.(*gelfOutput)
+		assert.Equal(t, "udp", g.cnf.network)
+		assert.Equal(t, "localhost:12201", g.cnf.addr)
+		assert.Equal(t, GELFGzip, g.cnf.compress)
+		assert.Equal(t, 1420, g.cnf.chunk)
+		assert.NotEmpty(t, g.cnf.host)
+	})
+}
+
+func TestGELFFormat(t *testing.T) {
+	now := time.Date(2026, 10, 17, 1, 2, 3, 456789000, time.UTC)
+	g := &gelfOutput{cnf: GELFConfig{host: "host"}}
+
+	testCases := []struct {
+		name   string
+		sample string
+		expect string
+	}{
+		{
+			name:   "Should map the message, level, time and other fields",
+			sample: `{"level":"WARNING","time":"2024-08-28T07:59:13.259+07:00","msg":"hello","code":200,"ok":true,"id":"x","bad key":"v","tags":["a"],"nil":null}`,
+			expect: `{"_bad_key":"v","_code":200,"_id_":"x","_ok":"true","_tags":"[\"a\"]","host":"host","level":4,"short_message":"hello","timestamp":1724806753.259,"version":"1.1"}`,
+		},
+		{
+			name:   "Given nested fields should flatten them using dot separated key",
+			sample: `{"level":"INFO","msg":"hello","req":{"id":"1","user":{"name":"a"}}}`,
+			expect: `{"_req.id":"1","_req.user.name":"a","host":"host","level":6,"short_message":"hello","timestamp":1792198923.456,"version":"1.1"}`,
+		},
+		{
+			name:   "Given stack trace should use it as the full message",
+			sample: `{"level":"ERROR","msg":"oops","stacktrace":"main.go:1"}`,
+			expect: `{"full_message":"main.go:1","host":"host","level":3,"short_message":"oops","timestamp":1792198923.456,"version":"1.1"}`,
+		},
+		{
+			name:   "Given empty message should use dash since short message is required",
+			sample: `{"level":"INFO"}`,
+			expect: `{"host":"host","level":6,"short_message":"-","timestamp":1792198923.456,"version":"1.1"}`,
+		},
+		{
+			name:   "Given not a JSON object should use it as the short message as is",
+			sample: `plain text`,
+			expect: `{"host":"host","level":5,"short_message":"plain text","timestamp":1792198923.456,"version":"1.1"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := g.format([]byte(tc.sample), now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, string(b))
+		})
+	}
+}
+
+func TestGELFPackets(t *testing.T) {
+	t.Run("Given tcp should terminate the message with null byte", func(t *testing.T) {
+		g := &gelfOutput{cnf: GELFConfig{network: "tcp", chunk: 20}}
+		p, err := g.packets([]byte(`{"short_message":"hello"}`))
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("{\"short_message\":\"hello\"}\x00")}, p)
+	})
+
+	t.Run("Given small message over udp should send it in a single datagram", func(t *testing.T) {
+		g := &gelfOutput{cnf: GELFConfig{network: "udp", compress: GELFUncompressed, chunk: 100}}
+		p, err := g.packets([]byte(`{"short_message":"hello"}`))
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte(`{"short_message":"hello"}`)}, p)
+	})
+
+	t.Run("Given large message over udp should chunk it", func(t *testing.T) {
+		g := &gelfOutput{cnf: GELFConfig{network: "udp", compress: GELFUncompressed, chunk: 22}}
+		msg := []byte(strings.Repeat("a", 25))
+		p, err := g.packets(msg)
+		require.NoError(t, err)
+		require.Len(t, p, 3)
+
+		var joined []byte
+		for i, c := range p {
+			assert.LessOrEqual(t, len(c), 22)
+			assert.Equal(t, []byte{0x1e, 0x0f}, c[:2])
+			assert.Equal(t, p[0][2:10], c[2:10], "should share the same message id")
+			assert.Equal(t, []byte{byte(i), 3}, c[10:12])
+			joined = append(joined, c[gelfChunkHeader:]...)
+		}
+		assert.Equal(t, msg, joined)
+	})
+
+	t.Run("Given message that need too many chunks should return error", func(t *testing.T) {
+		g := &gelfOutput{cnf: GELFConfig{network: "udp", compress: GELFUncompressed, chunk: 13}}
+		_, err := g.packets([]byte(strings.Repeat("a", 129)))
+		assert.Error(t, err)
+	})
+}
+
+func TestGELFWriter(t *testing.T) {
+	t.Run("Given udp should compress and chunk large message", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			comp GELFCompression
+		}{
+			{name: "gzip", comp: GELFGzip},
+			{name: "zlib", comp: GELFZlib},
+			{name: "uncompressed", comp: GELFUncompressed},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+				require.NoError(t, err)
+				ch := gelfPacketServer(t, conn)
+
+				wr := NewGELFWriter(InfoLevel, NewConfig(
+					WithGELFAddress(conn.LocalAddr().String()),
+					WithGELFCompression(tc.comp),
+					WithGELFChunkSize(100),
+				))
+				defer wr.Flush(0)
+				wr.Wait(time.Second)
+
+				// random like content so it's still large after compressed
+				var big strings.Builder
+				for i := 0; i < 200; i++ {
+					big.WriteString(time.Duration(i * 7919).String())
+				}
+				_, err = wr.Writer().Write([]byte(`{"level":"INFO","msg":"hello","big":"` + big.String() + `"}`))
+				require.NoError(t, err)
+
+				msg := receiveGELF(t, ch)
+				assert.Equal(t, "hello", msg["short_message"])
+				assert.Equal(t, big.String(), msg["_big"])
+			})
+		}
+	})
+
+	t.Run("Given tcp should send null delimited message and reconnect if the connection is lost", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		ch := make(chan string, 10)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					rd := bufio.NewReader(conn)
+					for {
+						s, err := rd.ReadString(0)
+						if err != nil {
+							return
+						}
+						ch <- strings.TrimSuffix(s, "\x00")
+					}
+				}()
+			}
+		}()
+
+		wr := NewGELFWriter(InfoLevel, NewConfig(WithGELFNetwork("tcp"), WithGELFAddress(ln.Addr().String())))
+		defer wr.Flush(0)
+		_, err = wr.Writer().Write([]byte(`{"level":"ERROR","msg":"first"}`))
+		require.NoError(t, err)
+		assert.Contains(t, receive(t, ch), `"short_message":"first"`)
+
+		// simulate lost connection
+		g := wr.(*gelfOutput)
+		g.mu.Lock()
+		_ = g.conn.Close()
+		g.mu.Unlock()
+
+		_, err = wr.Writer().Write([]byte(`{"level":"ERROR","msg":"second"}`))
+		require.NoError(t, err)
+		assert.Contains(t, receive(t, ch), `"short_message":"second"`)
+	})
+
+	t.Run("Given unreachable graylog should return error", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		wr := NewGELFWriter(InfoLevel, NewConfig(WithGELFNetwork("tcp"), WithGELFAddress(addr)))
+		wr.Wait(100 * time.Millisecond)
+		_, err = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.Error(t, err)
+		// should not dial again until the backoff is passed
+		_, err = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.ErrorContains(t, err, "waiting to reconnect")
+		wr.Flush(-1)
+	})
+}
+
+func TestGELFWriterStalled(t *testing.T) {
+	t.Run("Given graylog that stop reading should not block Write forever", func(t *testing.T) {
+		ln := stalledListener(t)
+		wr := NewGELFWriter(InfoLevel, NewConfig(WithGELFNetwork("tcp"), WithGELFAddress(ln.Addr().String())))
+		wr.(*gelfOutput).timeout = 50 * time.Millisecond
+		defer wr.Flush(0)
+
+		msg := []byte(`{"msg":"` + strings.Repeat("a", 1<<20) + `"}`)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// keep writing until the socket buffer is full
+			for i := 0; i < 32; i++ {
+				_, _ = wr.Writer().Write(msg)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(30 * time.Second):
+			t.Fatal("Write is blocked by the stalled graylog")
+		}
+	})
+}
+
+func TestGELFWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should send each log as GELF message", func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			ch := gelfPacketServer(t, conn)
+
+			wr := tc.fn(NewGELFWriter(InfoLevel, NewConfig(WithGELFAddress(conn.LocalAddr().String()))))
+			wr.Init(time.Second)
+			wr.Dbg("debug log")
+			wr.Wrn("warn log", String("key", "val"))
+			wr.Flush(time.Second)
+
+			msg := receiveGELF(t, ch)
+			assert.Equal(t, "warn log", msg["short_message"])
+			assert.Equal(t, json.Number("4"), msg["level"])
+			assert.Equal(t, "val", msg["_key"])
+		})
+	}
+}
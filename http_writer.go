@@ -0,0 +1,263 @@
+package apilog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewHTTPWriter return Writer implementer that send logs in batches to HTTP
+// endpoint that accept JSON array of logs by given Config and set given lvl
+// as the log Level. Each batch is sent when it reach the maximum number of
+// logs or bytes, or every batch interval, whichever comes first.
+func NewHTTPWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+	return newHTTPBatcher(HTTP, lvl, cnf.http, encodeJSONArray)
+}
+
+// encodeJSONArray encode given JSON encoded logs as JSON array.
+func encodeJSONArray(entries [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	buf.Write(bytes.Join(entries, []byte{','}))
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// newHTTPBatcher return httpBatcher that encode each batch using given encode
+// and send it using given cnf after setting the default value.
+func newHTTPBatcher(out Output, lvl Level, cnf HTTPConfig, encode func([][]byte) ([]byte, error)) *httpBatcher {
+	// set default value
+	if cnf.method == "" {
+		cnf.method = http.MethodPost
+	}
+	if cnf.contentType == "" {
+		cnf.contentType = "application/json"
+	}
+	if cnf.count <= 0 {
+		cnf.count = 500
+	}
+	if cnf.bytes <= 0 {
+		cnf.bytes = 1 << 20
+	}
+	if cnf.interval <= 0 {
+		cnf.interval = time.Second
+	}
+	if cnf.retry == 0 {
+		cnf.retry = 5
+	}
+	if cnf.backoff <= 0 {
+		cnf.backoff = 500 * time.Millisecond
+	}
+	if cnf.client == nil {
+		cnf.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	cnf.header = cnf.header.Clone()
+
+	h := &httpBatcher{
+		out:    out,
+		lvl:    NewAtomicLevel(lvl),
+		cnf:    cnf,
+		encode: encode,
+	}
+	h.batcher = newBatcher(cnf.count, cnf.bytes, cnf.interval, h.send, cnf.onError)
+	return h
+}
+
+// httpBatcher batch the logs then send each batch to HTTP endpoint in the
+// background, retrying with exponential backoff on server error or rate
+// limit. Shared by every Writer that send logs over HTTP.
+type httpBatcher struct {
+	*batcher
+	out    Output
+	lvl    *AtomicLevel
+	cnf    HTTPConfig
+	encode func([][]byte) ([]byte, error)
+
+	// partial check the response of the succeed request whose entries may be
+	// partially rejected, then return the rejected entries that should be
+	// retried and the error of the dropped ones, or why they are retried if
+	// none is dropped. Optional.
+	partial func(ctx context.Context, entries [][]byte, resp []byte) (retry [][]byte, dropped int, err error)
+	// statusErr return the error of the unexpected response status code using
+	// the response body. Optional.
+	statusErr func(code int, body []byte) error
+	// split split given batch into smaller ones that are sent separately e.g.
+	// to honor the payload limit. Optional.
+	split func(entries [][]byte) [][][]byte
+}
+
+// send send given entries in a single request, or one request per split
+// batch if split is set.
+func (h *httpBatcher) send(ctx context.Context, entries [][]byte) error {
+	if h.split == nil {
+		return h.sendBatch(ctx, entries)
+	}
+	var errs []error
+	for _, e := range h.split(entries) {
+		if err := h.sendBatch(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendBatch encode then send given entries, retrying with exponential backoff
+// on network error, server error or rate limit until the maximum number of
+// retries is reached or ctx is done.
+func (h *httpBatcher) sendBatch(ctx context.Context, entries [][]byte) error {
+	var failed error
+	for attempt := 0; ; attempt++ {
+		body, err := h.body(entries)
+		if err != nil {
+			return h.fail(len(entries), err)
+		}
+
+		var wait time.Duration
+		resp, retry, err := h.post(ctx, body)
+		if err == nil && h.partial != nil {
+			// only retry the rejected entries
+			var dropped int
+			var pErr error
+			if entries, dropped, pErr = h.partial(ctx, entries, resp); dropped > 0 {
+				failed = h.fail(dropped, pErr)
+			}
+			if len(entries) > 0 {
+				retry, err = true, fmt.Errorf("%d logs are rejected", len(entries))
+				if dropped == 0 && pErr != nil {
+					err = pErr
+				}
+			}
+		}
+		if err == nil {
+			return failed
+		}
+		if re, ok := err.(retryAfterError); ok {
+			wait = re.after
+		}
+		if !retry || attempt >= max(h.cnf.retry, 0) || ctx.Err() != nil {
+			return h.fail(len(entries), err)
+		}
+
+		if wait <= 0 {
+			wait = backoffOf(h.cnf.backoff, attempt)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return h.fail(len(entries), ctx.Err())
+		}
+	}
+}
+
+// body return the encoded entries, compressed using gzip unless disabled.
+func (h *httpBatcher) body(entries [][]byte) ([]byte, error) {
+	b, err := h.encode(entries)
+	if err != nil || h.cnf.noGzip {
+		return b, err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err = gz.Write(b); err != nil {
+		return nil, err
+	}
+	if err = gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// post send given body once then return the response body if succeed. Return
+// true if it should be retried.
+func (h *httpBatcher) post(ctx context.Context, body []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, h.cnf.method, h.cnf.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	for k, v := range h.cnf.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", h.cnf.contentType)
+	if !h.cnf.noGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := h.cnf.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if h.partial == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil, false, nil
+		}
+		b, err := io.ReadAll(resp.Body)
+		return b, err != nil, err
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	if h.statusErr != nil {
+		err = h.statusErr(resp.StatusCode, msg)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		if s, sErr := strconv.Atoi(resp.Header.Get("Retry-After")); sErr == nil && s > 0 {
+			return nil, true, retryAfterError{error: err, after: time.Duration(s) * time.Second}
+		}
+		return nil, true, err
+	}
+	return nil, false, err
+}
+
+func (h *httpBatcher) Writer() io.Writer         { return h }
+func (h *httpBatcher) Output() Output            { return h.out }
+func (h *httpBatcher) Level() Level              { return h.lvl.Level() }
+func (h *httpBatcher) AtomicLevel() *AtomicLevel { return h.lvl }
+func (h *httpBatcher) Wait(_ time.Duration)      {}
+
+// retryAfterError error of response that tell when to retry.
+type retryAfterError struct {
+	error
+	after time.Duration
+}
+
+func (r retryAfterError) Unwrap() error { return r.error }
+
+// maxBackoff maximum wait time between retries.
+const maxBackoff = 30 * time.Second
+
+// backoffOf return the wait time before given retry attempt, doubled on each
+// attempt starting from given initial and capped at maxBackoff.
+func backoffOf(initial time.Duration, attempt int) time.Duration {
+	if attempt > 16 {
+		return maxBackoff
+	}
+	return min(initial<<attempt, maxBackoff)
+}
+
+// endpointURL return given base URL, or def if empty, after setting its path
+// to given path if it has no path.
+func endpointURL(base, def, path string) string {
+	if base == "" {
+		base = def
+	}
+	u, err := url.Parse(base)
+	if err != nil || strings.Trim(u.Path, "/") != "" {
+		return base
+	}
+	u.Path = path
+	return u.String()
+}
@@ -0,0 +1,318 @@
+package apilog
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// httpRequest request received by the test server.
+type httpRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newHTTPServer return httptest.Server that decompress the body of each request
+// then send it to the returned channel. Respond with the status returned by
+// given status if any, otherwise 204.
+func newHTTPServer(t *testing.T, status func(n int) int) (*httptest.Server, <-chan httpRequest) {
+	ch := make(chan httpRequest, 16)
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rd io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			rd = gz
+		}
+		body, _ := io.ReadAll(rd)
+		code := http.StatusNoContent
+		if status != nil {
+			code = status(int(n.Add(1)))
+		}
+		if code < 300 {
+			ch <- httpRequest{header: r.Header, body: body}
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+// receiveRequest return the next request from given ch or fail after a second.
+func receiveRequest(t *testing.T, ch <-chan httpRequest) httpRequest {
+	select {
+	case req := <-ch:
+		return req
+	case <-time.After(time.Second):
+		t.Fatal("no request received")
+	}
+	return httpRequest{}
+}
+
+// decodeBatch decode given JSON array of logs.
+func decodeBatch(t *testing.T, b []byte) []map[string]any {
+	var logs []map[string]any
+	require.NoError(t, json.Unmarshal(b, &logs))
+	return logs
+}
+
+func TestNewHTTPWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewHTTPWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &httpBatcher{}, wr.Writer())
+		assert.Equal(t, HTTP, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, http.MethodPost, h.cnf.method)
+		assert.Equal(t, "application/json", h.cnf.contentType)
+		assert.Equal(t, 500, h.cnf.count)
+		assert.Equal(t, 1<<20, h.cnf.bytes)
+		assert.Equal(t, time.Second, h.cnf.interval)
+		assert.Equal(t, 5, h.cnf.retry)
+		assert.Equal(t, 500*time.Millisecond, h.cnf.backoff)
+		assert.NotNil(t, h.cnf.client)
+	})
+}
+
+func TestHTTPWriterBatching(t *testing.T) {
+	testCases := []struct {
+		name   string
+		opts   []ConfigOpt
+		expect int
+	}{
+		{
+			name:   "Should send the batch when reaching the maximum number of logs",
+			opts:   []ConfigOpt{WithHTTPBatchSize(2)},
+			expect: 2,
+		},
+		{
+			name:   "Should send the batch when reaching the maximum bytes",
+			opts:   []ConfigOpt{WithHTTPBatchBytes(30)},
+			expect: 2,
+		},
+		{
+			name:   "Should send the batch every batch interval",
+			opts:   []ConfigOpt{WithHTTPBatchInterval(50 * time.Millisecond)},
+			expect: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, ch := newHTTPServer(t, nil)
+			opts := append([]ConfigOpt{WithHTTPURL(srv.URL), WithHTTPBatchInterval(time.Hour)}, tc.opts...)
+			wr := NewHTTPWriter(InfoLevel, NewConfig(opts...))
+			defer wr.Flush(time.Second)
+
+			for _, msg := range []string{`{"msg":"first"}`, `{"msg":"second"}`, `{"msg":"third"}`} {
+				_, err := wr.Writer().Write([]byte(msg + "\n"))
+				require.NoError(t, err)
+			}
+			logs := decodeBatch(t, receiveRequest(t, ch).body)
+			require.Len(t, logs, tc.expect)
+			assert.Equal(t, "first", logs[0]["msg"])
+		})
+	}
+}
+
+func TestHTTPWriterRequest(t *testing.T) {
+	srv, ch := newHTTPServer(t, nil)
+
+	testCases := []struct {
+		name     string
+		opts     []ConfigOpt
+		encoding string
+	}{
+		{name: "Should compress the body using gzip by default", encoding: "gzip"},
+		{name: "Should not compress the body if gzip is disabled", opts: []ConfigOpt{WithHTTPGzip(false)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]ConfigOpt{WithHTTPURL(srv.URL), WithHTTPHeader("Authorization", "Bearer token")}, tc.opts...)
+			wr := NewHTTPWriter(InfoLevel, NewConfig(opts...))
+			_, err := wr.Writer().Write([]byte(`{"msg":"hello"}`))
+			require.NoError(t, err)
+			wr.Flush(time.Second)
+
+			req := receiveRequest(t, ch)
+			assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+			assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+			assert.Equal(t, tc.encoding, req.header.Get("Content-Encoding"))
+			assert.JSONEq(t, `[{"msg":"hello"}]`, string(req.body))
+		})
+	}
+}
+
+// failFirst return status func that respond with given code for the first n
+// requests then 200 after that.
+func failFirst(n, code int) func(int) int {
+	return func(i int) int {
+		if i <= n {
+			return code
+		}
+		return http.StatusOK
+	}
+}
+
+func TestHTTPWriterRetry(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status func(n int) int
+		retry  int
+		expect int32 // number of requests
+		err    bool
+	}{
+		{
+			name:   "Given server error should retry until succeed",
+			status: failFirst(2, http.StatusServiceUnavailable),
+			retry:  5,
+			expect: 3,
+		},
+		{
+			name:   "Given rate limit should retry until succeed",
+			status: failFirst(1, http.StatusTooManyRequests),
+			retry:  5,
+			expect: 2,
+		},
+		{
+			name:   "Given server error should give up after the maximum number of retries",
+			status: func(int) int { return http.StatusInternalServerError },
+			retry:  2,
+			expect: 3,
+			err:    true,
+		},
+		{
+			name:   "Given client error should not retry",
+			status: func(int) int { return http.StatusBadRequest },
+			retry:  5,
+			expect: 1,
+			err:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var n atomic.Int32
+			srv, _ := newHTTPServer(t, func(i int) int {
+				n.Add(1)
+				return tc.status(i)
+			})
+
+			var mu sync.Mutex
+			var errs []error
+			wr := NewHTTPWriter(InfoLevel, NewConfig(
+				WithHTTPURL(srv.URL),
+				WithHTTPRetry(tc.retry, time.Millisecond),
+				WithHTTPErrorHandler(func(err error) {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}),
+			))
+			_, err := wr.Writer().Write([]byte(`{"msg":"hello"}`))
+			require.NoError(t, err)
+			wr.Flush(time.Second)
+
+			assert.Equal(t, tc.expect, n.Load())
+			mu.Lock()
+			defer mu.Unlock()
+			if tc.err {
+				require.Len(t, errs, 1)
+				assert.Contains(t, errs[0].Error(), "failed to send 1 logs")
+				return
+			}
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestHTTPWriterFlush(t *testing.T) {
+	t.Run("Should give up the pending request once the deadline is passed", func(t *testing.T) {
+		block := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-block
+		}))
+		defer srv.Close()
+		defer close(block)
+
+		var failed atomic.Bool
+		wr := NewHTTPWriter(InfoLevel, NewConfig(
+			WithHTTPURL(srv.URL),
+			WithHTTPErrorHandler(func(err error) { failed.Store(errors.Is(err, context.Canceled)) }),
+		))
+		_, err := wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		require.NoError(t, err)
+
+		start := time.Now()
+		wr.Flush(100 * time.Millisecond)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.True(t, failed.Load())
+	})
+
+	t.Run("Should send logs written after flushed directly", func(t *testing.T) {
+		srv, ch := newHTTPServer(t, nil)
+		wr := NewHTTPWriter(InfoLevel, NewConfig(WithHTTPURL(srv.URL)))
+		wr.Flush(time.Second)
+
+		_, err := wr.Writer().Write([]byte(`{"msg":"late"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"msg":"late"}]`, string(receiveRequest(t, ch).body))
+	})
+}
+
+func TestHTTPWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should send each log as JSON object within the batch", func(t *testing.T) {
+			srv, ch := newHTTPServer(t, nil)
+			wr := tc.fn(NewHTTPWriter(InfoLevel, NewConfig(WithHTTPURL(srv.URL))))
+			wr.Init(time.Microsecond)
+			wr.Dbg("debug log")
+			wr.Inf("info log", String("key", "val"))
+			wr.Err("error log")
+			wr.Flush(time.Second)
+
+			logs := decodeBatch(t, receiveRequest(t, ch).body)
+			require.Len(t, logs, 2)
+			assert.Equal(t, "info log", logs[0]["msg"])
+			assert.Equal(t, "val", logs[0]["key"])
+			assert.Equal(t, "ERROR", logs[1]["level"])
+		})
+	}
+}
+
+func TestBackoffOf(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, backoffOf(100*time.Millisecond, 0))
+	assert.Equal(t, 400*time.Millisecond, backoffOf(100*time.Millisecond, 2))
+	assert.Equal(t, maxBackoff, backoffOf(time.Second, 10))
+	assert.Equal(t, maxBackoff, backoffOf(time.Second, 100))
+}
+
+func TestEndpointURL(t *testing.T) {
+	def, path := "http://localhost:3100", "/loki/api/v1/push"
+	assert.Equal(t, "http://localhost:3100/loki/api/v1/push", endpointURL("", def, path))
+	assert.Equal(t, "http://loki:3100/loki/api/v1/push", endpointURL("http://loki:3100", def, path))
+	assert.Equal(t, "https://loki/loki/api/v1/push", endpointURL("https://loki/", def, path))
+	assert.Equal(t, "https://gateway/custom/push", endpointURL("https://gateway/custom/push", def, path))
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package apilog
+
+import (
+	"errors"
+	"net"
+)
+
+// isMsgSize always return false, since journald is only available on unix.
+func isMsgSize(error) bool { return false }
+
+// sendJournalFile always return error, since passing file descriptor is only
+// supported on unix.
+func sendJournalFile(*net.UnixConn, []byte) error {
+	return errors.New("journal entry is too large")
+}
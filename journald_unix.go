@@ -0,0 +1,51 @@
+//go:build unix
+
+package apilog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// isMsgSize return true if given err is caused by datagram that is too large.
+func isMsgSize(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile send given entry that is too large to be sent as a single
+// datagram by writing it to an unlinked temporary file, preferably in
+// /dev/shm, then passing its file descriptor to journald as sd_journal does.
+func sendJournalFile(conn *net.UnixConn, entry []byte) error {
+	dir := "/dev/shm"
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "apilog-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err = f.Write(entry); err != nil {
+		return err
+	}
+
+	// WriteMsgUnix does not support connected datagram socket
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sErr error
+	err = rc.Write(func(fd uintptr) bool {
+		sErr = syscall.Sendmsg(int(fd), nil, syscall.UnixRights(int(f.Fd())), nil, 0)
+		return sErr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sErr
+}
@@ -0,0 +1,235 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// journalReserved journal fields that are set by JOURNALD Writer itself.
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+}
+
+// NewJournaldWriter return Writer implementer that send logs to systemd
+// journald using the native protocol by given Config and set given lvl as the
+// log Level. Each field become uppercase journal field e.g. request_id to
+// REQUEST_ID, nested fields e.g. from Group are flattened using underscore,
+// the message become MESSAGE, the Level is mapped to PRIORITY and the caller
+// if any is split into CODE_FILE and CODE_LINE. Field that collide with those
+// is prefixed by X_ e.g. X_MESSAGE. Connect lazily and reconnect once on each
+// failed write.
+func NewJournaldWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	j := &journaldOutput{lvl: NewAtomicLevel(lvl), cnf: cnf.journald}
+	// set default value
+	if j.cnf.socket == "" {
+		j.cnf.socket = "/run/systemd/journal/socket"
+	}
+	if j.cnf.identifier == "" {
+		j.cnf.identifier = filepath.Base(os.Args[0])
+	}
+	return j
+}
+
+type journaldOutput struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+	cnf  JournaldConfig
+	lvl  *AtomicLevel
+}
+
+// Write implement io.Writer by sending given JSON encoded log as journal
+// entry. Entry that is too large to be sent as a single datagram is sent
+// using a file descriptor instead.
+func (j *journaldOutput) Write(p []byte) (int, error) {
+	entry := j.format(bytes.TrimSpace(p))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		if err := j.dial(0); err != nil {
+			return 0, err
+		}
+	}
+	if err := j.send(entry); err != nil {
+		// reconnect then retry once
+		_ = j.conn.Close()
+		j.conn = nil
+		if err = j.dial(0); err != nil {
+			return 0, err
+		}
+		if err = j.send(entry); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// send write given entry to the current connection.
+func (j *journaldOutput) send(entry []byte) error {
+	_, err := j.conn.Write(entry)
+	if isMsgSize(err) {
+		return sendJournalFile(j.conn, entry)
+	}
+	return err
+}
+
+// format return given JSON encoded log as journal entry. The log is used as
+// the MESSAGE as is if it's not a JSON object.
+func (j *journaldOutput) format(p []byte) []byte {
+	var b []byte
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(toSyslogSeverity(levelOf(p))))
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", j.cnf.identifier)
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return appendJournalField(b, "MESSAGE", string(p))
+	}
+
+	if s, ok := fields["msg"].(string); ok {
+		b = appendJournalField(b, "MESSAGE", s)
+	}
+	if s, ok := fields["caller"].(string); ok {
+		if i := strings.LastIndexByte(s, ':'); i > 0 {
+			b = appendJournalField(b, "CODE_FILE", s[:i])
+			b = appendJournalField(b, "CODE_LINE", s[i+1:])
+		}
+	}
+	for _, k := range []string{"level", "time", "msg", "caller"} {
+		delete(fields, k)
+	}
+	return appendJournalFields(b, "", fields)
+}
+
+// appendJournalFields append given fields as journal fields sorted by the
+// key. Nested object is flattened using underscore separated key prefixed by
+// given prefix. Value that is not a string is encoded as JSON and null is
+// skipped. Key that collide with the reserved fields is prefixed by X_.
+func appendJournalFields(b []byte, prefix string, fields map[string]any) []byte {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case nil:
+		case map[string]any:
+			b = appendJournalFields(b, prefix+k+"_", v)
+		case string:
+			b = appendJournalField(b, journalUserField(prefix+k), v)
+		default:
+			s, _ := json.Marshal(v)
+			b = appendJournalField(b, journalUserField(prefix+k), string(s))
+		}
+	}
+	return b
+}
+
+// journalUserField return the journal field name of given key, prefixed by X_
+// if it collide with the reserved fields.
+func journalUserField(k string) string {
+	name := journalFieldName(k)
+	if journalReserved[name] {
+		return "X_" + name
+	}
+	return name
+}
+
+// appendJournalField append given field using the native protocol. Value that
+// contains newline is prefixed by its little endian 64-bit length instead.
+func appendJournalField(b []byte, k, v string) []byte {
+	b = append(b, k...)
+	if !strings.ContainsRune(v, '\n') {
+		b = append(b, '=')
+		b = append(b, v...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(v)))
+	b = append(b, v...)
+	return append(b, '\n')
+}
+
+// journalFieldName return given key as valid journal field name that only
+// contains uppercase letter, digit and underscore, start with a letter and at
+// most 64 characters long.
+func journalFieldName(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, k)
+	// leading underscore is reserved for trusted fields set by journald
+	k = strings.TrimLeft(k, "_")
+	if k == "" || k[0] < 'A' {
+		k = "X" + k
+	}
+	if len(k) > 64 {
+		k = k[:64]
+	}
+	return k
+}
+
+// dial connect to the journald socket within given timeout. No timeout if
+// zero.
+func (j *journaldOutput) dial(timeout time.Duration) error {
+	d := &net.Dialer{Timeout: timeout}
+	conn, err := d.Dial("unixgram", j.cnf.socket)
+	if err != nil {
+		return err
+	}
+	j.conn = conn.(*net.UnixConn)
+	return nil
+}
+
+func (j *journaldOutput) Writer() io.Writer         { return j }
+func (j *journaldOutput) Output() Output            { return JOURNALD }
+func (j *journaldOutput) Level() Level              { return j.lvl.Level() }
+func (j *journaldOutput) AtomicLevel() *AtomicLevel { return j.lvl }
+
+// Wait try to connect to the journald socket within given dur.
+func (j *journaldOutput) Wait(dur time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil && dur > 0 {
+		_ = j.dial(dur)
+	}
+}
+
+// Flush close the connection to the journald socket.
+func (j *journaldOutput) Flush(_ time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn != nil {
+		_ = j.conn.Close()
+		j.conn = nil
+	}
+}
@@ -0,0 +1,267 @@
+//go:build unix
+
+package apilog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseJournalEntry parse given journal entry encoded using the native
+// protocol.
+func parseJournalEntry(t *testing.T, b []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		require.GreaterOrEqual(t, i, 0)
+		k := string(b[:i])
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			fields[k] = string(b[i+1 : end])
+			b = b[end+1:]
+			continue
+		}
+		n := int(binary.LittleEndian.Uint64(b[i+1 : i+9]))
+		fields[k] = string(b[i+9 : i+9+n])
+		require.Equal(t, byte('\n'), b[i+9+n])
+		b = b[i+10+n:]
+	}
+	return fields
+}
+
+// journaldServer listen on unixgram socket in a temporary directory then send
+// each received entry, read from the passed file descriptor if any, to the
+// returned channel.
+func journaldServer(t *testing.T) (string, <-chan []byte) {
+	dir, err := os.MkdirTemp("", "journal")
+	require.NoError(t, err)
+	sock := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = os.RemoveAll(dir)
+	})
+
+	ch := make(chan []byte, 16)
+	go func() {
+		buf := make([]byte, 1<<16)
+		oob := make([]byte, 64)
+		for {
+			n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+			if err != nil {
+				return
+			}
+			if oobn == 0 {
+				ch <- bytes.Clone(buf[:n])
+				continue
+			}
+			msgs, _ := syscall.ParseSocketControlMessage(oob[:oobn])
+			fds, _ := syscall.ParseUnixRights(&msgs[0])
+			f := os.NewFile(uintptr(fds[0]), "journal")
+			_, _ = f.Seek(0, io.SeekStart)
+			b, _ := io.ReadAll(f)
+			_ = f.Close()
+			ch <- b
+		}
+	}()
+	return sock, ch
+}
+
+// receiveEntry return the next received entry or fail after a second.
+func receiveEntry(t *testing.T, ch <-chan []byte) map[string]string {
+	select {
+	case b := <-ch:
+		return parseJournalEntry(t, b)
+	case <-time.After(time.Second):
+		t.Fatal("no entry received")
+	}
+	return nil
+}
+
+func TestNewJournaldWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewJournaldWriter(WarnLevel, nil)
+		assert.IsType(t, &journaldOutput{}, wr.Writer())
+		assert.Equal(t, JOURNALD, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		j := wr.(*journaldOutput)
+		assert.Equal(t, "/run/systemd/journal/socket", j.cnf.socket)
+		assert.NotEmpty(t, j.cnf.identifier)
+	})
+}
+
+func TestJournalFieldName(t *testing.T) {
+	testCases := []struct {
+		sample string
+		expect string
+	}{
+		{sample: "request_id", expect: "REQUEST_ID"},
+		{sample: "req.user-id", expect: "REQ_USER_ID"},
+		{sample: "_private", expect: "PRIVATE"},
+		{sample: "1st", expect: "X1ST"},
+		{sample: "__", expect: "X"},
+		{sample: strings.Repeat("a", 70), expect: strings.Repeat("A", 64)},
+	}
+	for _, tc := range testCases {
+		t.Run("Given "+tc.sample+" should be "+tc.expect, func(t *testing.T) {
+			assert.Equal(t, tc.expect, journalFieldName(tc.sample))
+		})
+	}
+}
+
+func TestJournaldFormat(t *testing.T) {
+	j := &journaldOutput{cnf: JournaldConfig{identifier: "api"}}
+
+	t.Run("Should map the message, level, caller and other fields", func(t *testing.T) {
+		b := j.format([]byte(`{"level":"ERROR","time":"2024-08-28T07:59:13+07:00","msg":"hello","caller":"apilog/main.go:42","code":200,"ok":true,"req":{"id":"1"},"stacktrace":"main.go:1\nmain.go:2","nil":null}`))
+		assert.Equal(t, map[string]string{
+			"PRIORITY":          "3",
+			"SYSLOG_IDENTIFIER": "api",
+			"MESSAGE":           "hello",
+			"CODE_FILE":         "apilog/main.go",
+			"CODE_LINE":         "42",
+			"CODE":              "200",
+			"OK":                "true",
+			"REQ_ID":            "1",
+			"STACKTRACE":        "main.go:1\nmain.go:2",
+		}, parseJournalEntry(t, b))
+	})
+
+	t.Run("Given field that collide with the reserved fields should prefix it", func(t *testing.T) {
+		b := j.format([]byte(`{"level":"INFO","msg":"hello","caller":"main.go:1","message":"other","priority":"high","syslog_identifier":"worker","code":{"file":"x.go","line":7}}`))
+		assert.Equal(t, map[string]string{
+			"PRIORITY":            "6",
+			"SYSLOG_IDENTIFIER":   "api",
+			"MESSAGE":             "hello",
+			"CODE_FILE":           "main.go",
+			"CODE_LINE":           "1",
+			"X_MESSAGE":           "other",
+			"X_PRIORITY":          "high",
+			"X_SYSLOG_IDENTIFIER": "worker",
+			"X_CODE_FILE":         "x.go",
+			"X_CODE_LINE":         "7",
+		}, parseJournalEntry(t, b))
+	})
+
+	t.Run("Given not a JSON object should use it as the message as is", func(t *testing.T) {
+		b := j.format([]byte("plain\ntext"))
+		assert.Equal(t, map[string]string{
+			"PRIORITY":          "5",
+			"SYSLOG_IDENTIFIER": "api",
+			"MESSAGE":           "plain\ntext",
+		}, parseJournalEntry(t, b))
+	})
+}
+
+func TestJournaldWriter(t *testing.T) {
+	t.Run("Should send each log as journal entry", func(t *testing.T) {
+		sock, ch := journaldServer(t)
+		wr := NewJournaldWriter(InfoLevel, NewConfig(WithJournaldSocket(sock), WithJournaldIdentifier("api")))
+		defer wr.Flush(0)
+		wr.Wait(time.Second)
+
+		_, err := wr.Writer().Write([]byte(`{"level":"WARNING","msg":"hello","tenant_id":"acme"}` + "\n"))
+		require.NoError(t, err)
+		entry := receiveEntry(t, ch)
+		assert.Equal(t, "hello", entry["MESSAGE"])
+		assert.Equal(t, "4", entry["PRIORITY"])
+		assert.Equal(t, "acme", entry["TENANT_ID"])
+	})
+
+	t.Run("Given entry that is too large for a datagram should send it using file descriptor", func(t *testing.T) {
+		sock, ch := journaldServer(t)
+		wr := NewJournaldWriter(InfoLevel, NewConfig(WithJournaldSocket(sock)))
+		defer wr.Flush(0)
+		wr.Wait(time.Second)
+
+		// larger than the default socket send buffer
+		big := strings.Repeat("x", 1<<20)
+		_, err := wr.Writer().Write([]byte(`{"level":"INFO","msg":"` + big + `"}`))
+		require.NoError(t, err)
+		assert.Equal(t, big, receiveEntry(t, ch)["MESSAGE"])
+	})
+
+	t.Run("Given journald that is restarted should reconnect", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "journal")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		sock := filepath.Join(dir, "socket")
+		listen := func() *net.UnixConn {
+			conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+			require.NoError(t, err)
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			return conn
+		}
+		read := func(conn *net.UnixConn) map[string]string {
+			buf := make([]byte, 1024)
+			n, err := conn.Read(buf)
+			require.NoError(t, err)
+			return parseJournalEntry(t, buf[:n])
+		}
+
+		conn := listen()
+		wr := NewJournaldWriter(InfoLevel, NewConfig(WithJournaldSocket(sock)))
+		defer wr.Flush(0)
+		_, err = wr.Writer().Write([]byte(`{"msg":"first"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "first", read(conn)["MESSAGE"])
+
+		// simulate restarted journald
+		require.NoError(t, conn.Close())
+		require.NoError(t, os.Remove(sock))
+		conn = listen()
+		defer conn.Close()
+
+		_, err = wr.Writer().Write([]byte(`{"msg":"second"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "second", read(conn)["MESSAGE"])
+	})
+
+	t.Run("Given unavailable socket should return error", func(t *testing.T) {
+		wr := NewJournaldWriter(InfoLevel, NewConfig(WithJournaldSocket(filepath.Join(t.TempDir(), "missing"))))
+		_, err := wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.Error(t, err)
+		wr.Flush(0)
+	})
+}
+
+func TestJournaldWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should send the caller as CODE_FILE and CODE_LINE", func(t *testing.T) {
+			sock, ch := journaldServer(t)
+			wr := tc.fn(NewJournaldWriter(InfoLevel, NewConfig(WithJournaldSocket(sock))))
+			wr.Init(time.Second)
+			wr = wr.WithOptions(WithCaller())
+			wr.Dbg("debug log")
+			wr.Inf("info log", String("request_id", "1"))
+			wr.Flush(time.Second)
+
+			entry := receiveEntry(t, ch)
+			assert.Equal(t, "info log", entry["MESSAGE"])
+			assert.Equal(t, "6", entry["PRIORITY"])
+			assert.Equal(t, "1", entry["REQUEST_ID"])
+			assert.True(t, strings.HasSuffix(entry["CODE_FILE"], "/journald_writer_test.go"))
+			assert.NotEmpty(t, entry["CODE_LINE"])
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package apilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// KafkaMessage single message produced to kafka.
+type KafkaMessage struct {
+	Topic string
+	Key   []byte // Key used to select the partition, nil if the log has no key field
+	Value []byte // Value JSON encoded log
+}
+
+// KafkaProducer minimal kafka producer used by the KAFKA Writer, so any kafka
+// client can be used by adapting it e.g. segmentio/kafka-go Writer or sarama
+// SyncProducer. The producer is owned by the caller and is not closed by
+// Flush.
+type KafkaProducer interface {
+	// Produce send given messages synchronously, return error if any of them
+	// failed to be delivered.
+	Produce(ctx context.Context, msgs []KafkaMessage) error
+}
+
+// NewKafkaWriter return Writer implementer that produce logs in batches to
+// kafka using the producer set by WithKafkaProducer by given Config and set
+// given lvl as the log Level. Each log is produced as a single message whose
+// key is the value of the key field if any, so logs with the same key e.g.
+// tenant_id land in the same partition. Each batch is produced when it reach
+// the maximum number of logs or bytes, or every linger duration, whichever
+// comes first. Batch that failed to be delivered is reported to the error
+// handler.
+func NewKafkaWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	k := &kafkaOutput{lvl: NewAtomicLevel(lvl), cnf: cnf.kafka}
+	// set default value
+	if k.cnf.topic == "" {
+		k.cnf.topic = "apilog"
+	}
+	if k.cnf.count <= 0 {
+		k.cnf.count = 500
+	}
+	if k.cnf.bytes <= 0 {
+		k.cnf.bytes = 1 << 20
+	}
+	if k.cnf.linger <= 0 {
+		k.cnf.linger = time.Second
+	}
+	k.batcher = newBatcher(k.cnf.count, k.cnf.bytes, k.cnf.linger, k.send, k.cnf.onError)
+	return k
+}
+
+type kafkaOutput struct {
+	*batcher
+	lvl *AtomicLevel
+	cnf KafkaConfig
+}
+
+// send produce given entries as messages.
+func (k *kafkaOutput) send(ctx context.Context, entries [][]byte) error {
+	if k.cnf.producer == nil {
+		return k.fail(len(entries), errors.New("no kafka producer is set"))
+	}
+
+	msgs := make([]KafkaMessage, len(entries))
+	for i, e := range entries {
+		msgs[i] = KafkaMessage{Topic: k.cnf.topic, Key: k.keyOf(e), Value: e}
+	}
+	if err := k.cnf.producer.Produce(ctx, msgs); err != nil {
+		return k.fail(len(entries), err)
+	}
+	return nil
+}
+
+// keyOf return the value of the key field of given JSON encoded log, nil if
+// the key field is not set or not found.
+func (k *kafkaOutput) keyOf(e []byte) []byte {
+	if k.cnf.key == "" {
+		return nil
+	}
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(e))
+	dec.UseNumber() // keep large number e.g. id as is
+	_ = dec.Decode(&fields)
+	if v, ok := fieldOf(fields, k.cnf.key); ok {
+		return []byte(v)
+	}
+	return nil
+}
+
+func (k *kafkaOutput) Writer() io.Writer         { return k }
+func (k *kafkaOutput) Output() Output            { return KAFKA }
+func (k *kafkaOutput) Level() Level              { return k.lvl.Level() }
+func (k *kafkaOutput) AtomicLevel() *AtomicLevel { return k.lvl }
+func (k *kafkaOutput) Wait(_ time.Duration)      {}
@@ -0,0 +1,149 @@
+package apilog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProducer in-memory KafkaProducer that record each produced batch.
+type fakeProducer struct {
+	mu      sync.Mutex
+	batches [][]KafkaMessage
+	err     error
+}
+
+func (f *fakeProducer) Produce(_ context.Context, msgs []KafkaMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, msgs)
+	return nil
+}
+
+func (f *fakeProducer) produced() [][]KafkaMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestNewKafkaWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewKafkaWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &kafkaOutput{}, wr.Writer())
+		assert.Equal(t, KAFKA, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		k := wr.(*kafkaOutput)
+		assert.Equal(t, "apilog", k.cnf.topic)
+		assert.Equal(t, 500, k.cnf.count)
+		assert.Equal(t, 1<<20, k.cnf.bytes)
+		assert.Equal(t, time.Second, k.cnf.linger)
+	})
+}
+
+func TestKafkaWriter(t *testing.T) {
+	t.Run("Should produce each log as message keyed by the key field", func(t *testing.T) {
+		p := &fakeProducer{}
+		wr := NewKafkaWriter(InfoLevel, NewConfig(
+			WithKafkaProducer(p),
+			WithKafkaTopic("audit"),
+			WithKafkaKey("tenant_id"),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"first","tenant_id":"acme"}` + "\n"))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"second","tenant_id":12345678901}`))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"third"}`))
+		wr.Flush(time.Second)
+
+		batches := p.produced()
+		require.Len(t, batches, 1)
+		assert.Equal(t, []KafkaMessage{
+			{Topic: "audit", Key: []byte("acme"), Value: []byte(`{"msg":"first","tenant_id":"acme"}`)},
+			{Topic: "audit", Key: []byte("12345678901"), Value: []byte(`{"msg":"second","tenant_id":12345678901}`)},
+			{Topic: "audit", Value: []byte(`{"msg":"third"}`)},
+		}, batches[0])
+	})
+
+	t.Run("Should produce the batch once it's full or every linger duration", func(t *testing.T) {
+		p := &fakeProducer{}
+		wr := NewKafkaWriter(InfoLevel, NewConfig(
+			WithKafkaProducer(p),
+			WithKafkaBatchSize(2),
+			WithKafkaLinger(10*time.Millisecond),
+		))
+		defer wr.Flush(time.Second)
+		for i := 0; i < 3; i++ {
+			_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		}
+
+		assert.Eventually(t, func() bool { return len(p.produced()) == 2 }, time.Second, time.Millisecond)
+		assert.Len(t, p.produced()[0], 2)
+		assert.Len(t, p.produced()[1], 1)
+	})
+
+	t.Run("Given delivery failure should report it to the error handler", func(t *testing.T) {
+		p := &fakeProducer{err: errors.New("leader not available")}
+		var reported error
+		wr := NewKafkaWriter(InfoLevel, NewConfig(
+			WithKafkaProducer(p),
+			WithKafkaErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		assert.ErrorIs(t, reported, p.err)
+		assert.EqualError(t, reported, "failed to send 1 logs: leader not available")
+	})
+
+	t.Run("Given no producer should report it to the error handler", func(t *testing.T) {
+		var reported error
+		wr := NewKafkaWriter(InfoLevel, NewConfig(WithKafkaErrorHandler(func(err error) { reported = err })))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		assert.EqualError(t, reported, "failed to send 1 logs: no kafka producer is set")
+	})
+
+	t.Run("Given already flushed should return the delivery failure directly", func(t *testing.T) {
+		p := &fakeProducer{err: errors.New("broker down")}
+		wr := NewKafkaWriter(InfoLevel, NewConfig(WithKafkaProducer(p)))
+		wr.Flush(time.Second)
+
+		_, err := wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.ErrorIs(t, err, p.err)
+	})
+}
+
+func TestKafkaWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should produce each log as message", func(t *testing.T) {
+			p := &fakeProducer{}
+			wr := tc.fn(NewKafkaWriter(InfoLevel, NewConfig(WithKafkaProducer(p), WithKafkaKey("tenant_id"))))
+			wr.Init(time.Second)
+			wr.Dbg("debug log")
+			wr.Inf("info log", String("tenant_id", "acme"))
+			wr.Flush(time.Second)
+
+			batches := p.produced()
+			require.Len(t, batches, 1)
+			require.Len(t, batches[0], 1)
+			assert.Equal(t, []byte("acme"), batches[0][0].Key)
+			assert.Contains(t, string(batches[0][0].Value), `"msg":"info log"`)
+		})
+	}
+}
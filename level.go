@@ -5,6 +5,13 @@ import "strings"
 // A Level is a logging priority. Higher levels are more important.
 type Level int8
 
+const (
+	// TraceLevel even more verbose than DebugLevel, used for very chatty
+	// diagnostics e.g. dumping payload body. Not -1, since it's already used
+	// by ParseLevel to indicate unrecognized level.
+	TraceLevel Level = DebugLevel - 2
+)
+
 const (
 	// DebugLevel most verbose logs, and are usually disabled in production.
 	DebugLevel Level = iota
@@ -16,12 +23,18 @@ const (
 	// ErrorLevel logs are high-priority. If an application is running smoothly,
 	// it shouldn't generate any error-level logs.
 	ErrorLevel
+	// PanicLevel logs a message, then panics.
+	PanicLevel
+	// FatalLevel logs a message, then calls os.Exit(1).
+	FatalLevel
 )
 
 // ParseLevel parses a level based on the lower-case representation of the log
 // level.
 func ParseLevel(lvl string) Level {
 	switch strings.ToLower(lvl) {
+	case "trace":
+		return TraceLevel
 	case "debug":
 		return DebugLevel
 	case "info":
@@ -30,6 +43,36 @@ func ParseLevel(lvl string) Level {
 		return WarnLevel
 	case "error", "err":
 		return ErrorLevel
+	case "panic":
+		return PanicLevel
+	case "fatal":
+		return FatalLevel
 	}
 	return -1
 }
+
+// String returns the lower-case representation of the log level.
+func (l Level) String() string {
+	switch l {
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case PanicLevel:
+		return "panic"
+	case FatalLevel:
+		return "fatal"
+	}
+	return "unknown"
+}
+
+// valid return true if l is one of the known Level.
+func (l Level) valid() bool {
+	return l == TraceLevel || l >= DebugLevel && l <= FatalLevel
+}
@@ -0,0 +1,84 @@
+package apilog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// NewLevelHandler return http.Handler that let operators check and change the
+// Level of each of the given named Writer at runtime.
+//
+// Supported requests:
+//   - GET: return the Level of every Writer, e.g. {"console":"info","file":"warn"}
+//   - GET ?writer=file: return the Level of the Writer named 'file', e.g. {"writer":"file","level":"warn"}
+//   - PUT ?writer=file with body {"level":"debug"}: change the Level of the Writer named 'file'
+func NewLevelHandler(wr map[string]Writer) http.Handler {
+	return &levelHandler{wr: wr}
+}
+
+type levelHandler struct {
+	wr map[string]Writer
+}
+
+type levelPayload struct {
+	Writer string `json:"writer,omitempty"`
+	Level  string `json:"level,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("writer")
+
+	switch r.Method {
+	case http.MethodGet:
+		if name == "" {
+			all := make(map[string]string, len(h.wr))
+			for k, wr := range h.wr {
+				all[k] = wr.Level().String()
+			}
+			writeLevelJSON(w, http.StatusOK, all)
+			return
+		}
+		wr, ok := h.wr[name]
+		if !ok {
+			writeLevelJSON(w, http.StatusNotFound, levelPayload{Error: "unknown writer: " + name})
+			return
+		}
+		writeLevelJSON(w, http.StatusOK, levelPayload{Writer: name, Level: wr.Level().String()})
+
+	case http.MethodPut:
+		wr, ok := h.wr[name]
+		if !ok {
+			writeLevelJSON(w, http.StatusNotFound, levelPayload{Error: "unknown writer: " + name})
+			return
+		}
+		al := atomicLevelOf(wr)
+		if al == nil {
+			writeLevelJSON(w, http.StatusBadRequest, levelPayload{Error: "level of writer " + name + " can not be changed"})
+			return
+		}
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelJSON(w, http.StatusBadRequest, levelPayload{Error: "invalid request body: " + err.Error()})
+			return
+		}
+		lvl := ParseLevel(req.Level)
+		if !lvl.valid() {
+			writeLevelJSON(w, http.StatusBadRequest, levelPayload{Error: "unrecognized level: " + req.Level})
+			return
+		}
+		al.SetLevel(lvl)
+		writeLevelJSON(w, http.StatusOK, levelPayload{Writer: name, Level: lvl.String()})
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelJSON(w, http.StatusMethodNotAllowed, levelPayload{Error: "only GET and PUT are supported"})
+	}
+}
+
+// writeLevelJSON write given v as JSON response with given status code.
+func writeLevelJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
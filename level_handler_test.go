@@ -0,0 +1,121 @@
+package apilog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedWriter Writer implementer whose Level can not be changed.
+type fixedWriter struct{ plainWriter }
+
+// plainWriter alias, so Writer can be embedded without hiding its methods.
+type plainWriter = Writer
+
+func TestNewLevelHandler(t *testing.T) {
+	cns := NewConsoleWriter(InfoLevel)
+	fl, _ := NewObserverWriter(WarnLevel, FILE)
+	h := NewLevelHandler(map[string]Writer{
+		"console": cns,
+		"file":    fl,
+		"fixed":   fixedWriter{NewConsoleWriter(ErrorLevel)},
+	})
+
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   int
+		expect string
+	}{
+		{
+			name:   "Get all writers",
+			method: http.MethodGet,
+			target: "/",
+			code:   http.StatusOK,
+			expect: `{"console":"info","file":"warn","fixed":"error"}`,
+		},
+		{
+			name:   "Get single writer",
+			method: http.MethodGet,
+			target: "/?writer=file",
+			code:   http.StatusOK,
+			expect: `{"writer":"file","level":"warn"}`,
+		},
+		{
+			name:   "Get unknown writer",
+			method: http.MethodGet,
+			target: "/?writer=syslog",
+			code:   http.StatusNotFound,
+			expect: `{"error":"unknown writer: syslog"}`,
+		},
+		{
+			name:   "Put unknown writer",
+			method: http.MethodPut,
+			target: "/?writer=syslog",
+			body:   `{"level":"debug"}`,
+			code:   http.StatusNotFound,
+			expect: `{"error":"unknown writer: syslog"}`,
+		},
+		{
+			name:   "Put writer that can not be changed",
+			method: http.MethodPut,
+			target: "/?writer=fixed",
+			body:   `{"level":"debug"}`,
+			code:   http.StatusBadRequest,
+			expect: `{"error":"level of writer fixed can not be changed"}`,
+		},
+		{
+			name:   "Put invalid body",
+			method: http.MethodPut,
+			target: "/?writer=file",
+			body:   `debug`,
+			code:   http.StatusBadRequest,
+			expect: `"error":"invalid request body`,
+		},
+		{
+			name:   "Put unrecognized level",
+			method: http.MethodPut,
+			target: "/?writer=file",
+			body:   `{"level":"loud"}`,
+			code:   http.StatusBadRequest,
+			expect: `{"error":"unrecognized level: loud"}`,
+		},
+		{
+			name:   "Put valid level",
+			method: http.MethodPut,
+			target: "/?writer=file",
+			body:   `{"level":"debug"}`,
+			code:   http.StatusOK,
+			expect: `{"writer":"file","level":"debug"}`,
+		},
+		{
+			name:   "Unsupported method",
+			method: http.MethodPost,
+			target: "/",
+			code:   http.StatusMethodNotAllowed,
+			expect: `{"error":"only GET and PUT are supported"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tc.expect)
+		})
+	}
+
+	t.Run("Changed level should be applied to the Writer", func(t *testing.T) {
+		assert.Equal(t, DebugLevel, fl.Level())
+		assert.Equal(t, InfoLevel, cns.Level())
+	})
+}
@@ -12,6 +12,11 @@ func TestParseLevel(t *testing.T) {
 		sample string
 		expect Level
 	}{
+		{
+			name:   "Trace",
+			sample: "trace",
+			expect: TraceLevel,
+		},
 		{
 			name:   "Debug",
 			sample: "debug",
@@ -42,6 +47,16 @@ func TestParseLevel(t *testing.T) {
 			sample: "error",
 			expect: ErrorLevel,
 		},
+		{
+			name:   "Panic",
+			sample: "panic",
+			expect: PanicLevel,
+		},
+		{
+			name:   "Fatal",
+			sample: "FATAL",
+			expect: FatalLevel,
+		},
 		{
 			name:   "Unrecognized",
 			sample: "hello",
@@ -55,3 +70,29 @@ func TestParseLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestLevelString(t *testing.T) {
+	testCases := []struct {
+		sample Level
+		expect string
+	}{
+		{sample: TraceLevel, expect: "trace"},
+		{sample: DebugLevel, expect: "debug"},
+		{sample: InfoLevel, expect: "info"},
+		{sample: WarnLevel, expect: "warn"},
+		{sample: ErrorLevel, expect: "error"},
+		{sample: PanicLevel, expect: "panic"},
+		{sample: FatalLevel, expect: "fatal"},
+		{sample: -1, expect: "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expect, func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.sample.String())
+			// should be able to parse back
+			if tc.sample.valid() {
+				assert.Equal(t, tc.sample, ParseLevel(tc.sample.String()))
+			}
+		})
+	}
+}
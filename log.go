@@ -1,5 +1,11 @@
 package apilog
 
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
 // Log object that holds data for each field inserted to each log message. How
 // Logger implementer is treating this object should read the field typ and
 // follow the guideline from Type and each of the supported types.
@@ -8,6 +14,8 @@ type Log struct {
 	key string
 	str string
 	num int
+	i64 int64
+	u64 uint64
 	flt float64
 	b   bool
 	any any
@@ -31,6 +39,36 @@ const (
 	AnyType
 	// ErrorType use field err from error interface of Log as the value.
 	ErrorType
+	// Int64Type use field i64 int64 of Log as the value.
+	Int64Type
+	// Uint64Type use field u64 uint64 of Log as the value.
+	Uint64Type
+	// DurationType use field i64 int64 of Log as the value in nanoseconds.
+	// Encoded as string e.g. '1.5s'.
+	DurationType
+	// TimeType use field any time.Time of Log as the value. Encoded as string
+	// in RFC3339 with nanoseconds format.
+	TimeType
+	// StringsType use field any []string of Log as the value.
+	StringsType
+	// IntsType use field any []int of Log as the value.
+	IntsType
+	// BytesType use field any []byte of Log as the value. Encoded as UTF-8
+	// string.
+	BytesType
+	// StringerType use field any fmt.Stringer of Log as the value. Encoded as
+	// string returned by its String method that never panics.
+	StringerType
+	// ObjectType use field any ObjectMarshaler of Log as the value. Encoded
+	// as nested object.
+	ObjectType
+	// LazyType use field any func() Log of Log as the value. The function is
+	// only called when the Log is actually written, then the returned Log is
+	// used instead using the same key.
+	LazyType
+	// SecretType use field str string of Log as the value. Always masked,
+	// either by the Masker from WithRedaction or MaskFull.
+	SecretType
 )
 
 // String constructs a Log with the given key and value. This set the type to
@@ -68,3 +106,112 @@ func Any(k string, any any) Log {
 func Error(err error) Log {
 	return Log{typ: ErrorType, key: "error", err: err}
 }
+
+// Int64 constructs a Log with the given key and value. This set the type to
+// Int64Type.
+func Int64(k string, i int64) Log {
+	return Log{typ: Int64Type, key: k, i64: i}
+}
+
+// Uint64 constructs a Log with the given key and value. This set the type to
+// Uint64Type.
+func Uint64(k string, u uint64) Log {
+	return Log{typ: Uint64Type, key: k, u64: u}
+}
+
+// Duration constructs a Log with the given key and value. This set the type to
+// DurationType.
+func Duration(k string, d time.Duration) Log {
+	return Log{typ: DurationType, key: k, i64: int64(d)}
+}
+
+// Time constructs a Log with the given key and value. This set the type to
+// TimeType.
+func Time(k string, t time.Time) Log {
+	return Log{typ: TimeType, key: k, any: t}
+}
+
+// Strings constructs a Log with the given key and value. This set the type to
+// StringsType.
+func Strings(k string, s []string) Log {
+	return Log{typ: StringsType, key: k, any: s}
+}
+
+// Ints constructs a Log with the given key and value. This set the type to
+// IntsType.
+func Ints(k string, nums []int) Log {
+	return Log{typ: IntsType, key: k, any: nums}
+}
+
+// Bytes constructs a Log with the given key and UTF-8 encoded value. This set
+// the type to BytesType.
+func Bytes(k string, b []byte) Log {
+	return Log{typ: BytesType, key: k, any: b}
+}
+
+// Stringer constructs a Log with the given key and value. The String method
+// is only called when the Log is actually written. This set the type to
+// StringerType.
+func Stringer(k string, v fmt.Stringer) Log {
+	return Log{typ: StringerType, key: k, any: safeStringer{v: v}}
+}
+
+// Object constructs a Log with the given key and ObjectMarshaler. This set the
+// type to ObjectType.
+func Object(k string, v ObjectMarshaler) Log {
+	return Log{typ: ObjectType, key: k, any: v}
+}
+
+// Lazy constructs a Log with the given key and function that return the actual
+// Log. The function is only called when at least one Writer will write the
+// log, so it's suitable for expensive value. This set the type to LazyType.
+func Lazy(k string, fn func() Log) Log {
+	return Log{typ: LazyType, key: k, any: fn}
+}
+
+// LazyAny same as Lazy but the returned value of the function is used the same
+// way as Any.
+func LazyAny(k string, fn func() any) Log {
+	return Lazy(k, func() Log { return Any(k, fn()) })
+}
+
+// Secret constructs a Log with the given key and sensitive value that is always
+// masked regardless of the key. This set the type to SecretType.
+func Secret(k, v string) Log {
+	return Log{typ: SecretType, key: k, str: v}
+}
+
+// resolve return the actual Log if it's LazyType, otherwise return as is.
+func (l Log) resolve() Log {
+	if l.typ != LazyType {
+		return l
+	}
+	r := l.any.(func() Log)()
+	r.key = l.key
+	return r.resolve()
+}
+
+// safeStringer fmt.Stringer that never panics.
+type safeStringer struct {
+	v fmt.Stringer
+}
+
+func (s safeStringer) String() string { return stringerOf(s.v) }
+
+// stringerOf safely call String method of given v, the same way zap does with
+// nil pointer and panic.
+func stringerOf(v fmt.Stringer) (s string) {
+	defer func() {
+		if err := recover(); err != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				s = "<nil>"
+				return
+			}
+			s = fmt.Sprintf("PANIC=%v", err)
+		}
+	}()
+	if v == nil {
+		return "<nil>"
+	}
+	return v.String()
+}
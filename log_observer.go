@@ -13,7 +13,7 @@ import (
 type ObservedLog struct {
 	mu   sync.RWMutex
 	logs []loggedLog
-	lvl  Level
+	lvl  *AtomicLevel
 	out  Output
 }
 
@@ -21,7 +21,9 @@ func (o *ObservedLog) Writer() io.Writer { return o }
 
 func (o *ObservedLog) Output() Output { return o.out }
 
-func (o *ObservedLog) Level() Level { return o.lvl }
+func (o *ObservedLog) Level() Level { return o.lvl.Level() }
+
+func (o *ObservedLog) AtomicLevel() *AtomicLevel { return o.lvl }
 
 func (o *ObservedLog) Wait(_ time.Duration) {}
 
@@ -80,7 +82,7 @@ func (o *ObservedLog) TakeAll() []loggedLog {
 // and also return ObservedLog to help assert and check logged Log(s).
 func NewObserverWriter(lvl Level, out Output) (Writer, *ObservedLog) {
 	ol := &ObservedLog{
-		lvl: lvl,
+		lvl: NewAtomicLevel(lvl),
 		out: out,
 		mu:  sync.RWMutex{},
 	}
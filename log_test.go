@@ -2,9 +2,12 @@ package apilog
 
 import (
 	"errors"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLog(t *testing.T) {
@@ -42,3 +45,148 @@ func TestLog(t *testing.T) {
 	assert.Equal(t, er, err.err)
 	assert.Equal(t, "oops", err.err.Error())
 }
+
+func TestTypedLog(t *testing.T) {
+	i64 := Int64("id", 1<<40)
+	assert.Equal(t, Int64Type, i64.typ)
+	assert.Equal(t, int64(1<<40), i64.i64)
+
+	u64 := Uint64("count", 1<<63)
+	assert.Equal(t, Uint64Type, u64.typ)
+	assert.Equal(t, uint64(1<<63), u64.u64)
+
+	dur := Duration("took", 1500*time.Millisecond)
+	assert.Equal(t, DurationType, dur.typ)
+	assert.Equal(t, int64(1500*time.Millisecond), dur.i64)
+
+	now := time.Now()
+	tm := Time("at", now)
+	assert.Equal(t, TimeType, tm.typ)
+	assert.Equal(t, now, tm.any)
+
+	strs := Strings("tags", []string{"a", "b"})
+	assert.Equal(t, StringsType, strs.typ)
+	assert.Equal(t, []string{"a", "b"}, strs.any)
+
+	ints := Ints("ids", []int{1, 2})
+	assert.Equal(t, IntsType, ints.typ)
+	assert.Equal(t, []int{1, 2}, ints.any)
+
+	bs := Bytes("body", []byte("hello"))
+	assert.Equal(t, BytesType, bs.typ)
+	assert.Equal(t, []byte("hello"), bs.any)
+
+	st := Stringer("ip", net.IPv4(127, 0, 0, 1))
+	assert.Equal(t, StringerType, st.typ)
+	assert.Equal(t, "127.0.0.1", st.any.(safeStringer).String())
+}
+
+// panicStringer fmt.Stringer that always panics.
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("oops") }
+
+func TestStringerOf(t *testing.T) {
+	var nilAddr *net.TCPAddr
+	assert.Equal(t, "<nil>", stringerOf(nil))
+	assert.Equal(t, "<nil>", stringerOf(nilAddr))
+	assert.Equal(t, "PANIC=oops", stringerOf(panicStringer{}))
+	assert.Equal(t, "127.0.0.1", stringerOf(net.IPv4(127, 0, 0, 1)))
+}
+
+func TestTypedLogAcrossBackends(t *testing.T) {
+	at := time.Date(2024, 8, 28, 7, 59, 13, 123456789, time.UTC)
+	pr := []Log{
+		Int64("id", 1<<40),
+		Uint64("count", 1<<63),
+		Duration("took", 1500*time.Millisecond),
+		Time("at", at),
+		Strings("tags", []string{"a", "b"}),
+		Ints("ids", []int{1, 2}),
+		Bytes("body", []byte("hello")),
+		Stringer("ip", net.IPv4(127, 0, 0, 1)),
+		Stringer("nil", nil),
+	}
+	expect := map[string]any{
+		"id":    float64(1 << 40),
+		"count": float64(1 << 63),
+		"took":  "1.5s",
+		"at":    "2024-08-28T07:59:13.123456789Z",
+		"tags":  []any{"a", "b"},
+		"ids":   []any{float64(1), float64(2)},
+		"body":  "hello",
+		"ip":    "127.0.0.1",
+		"nil":   "<nil>",
+	}
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should encode each type the same way", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer)
+			wr.Init(time.Microsecond)
+			wr.Inf("typed", pr...)
+
+			require.Equal(t, 1, obs.Len())
+			for k, v := range expect {
+				assert.Equal(t, v, obs.All()[0].Get(k), k)
+			}
+		})
+	}
+}
+
+func TestLazy(t *testing.T) {
+	t.Run("Should resolve to the returned Log using the same key", func(t *testing.T) {
+		lz := Lazy("body", func() Log { return String("ignored", "payload") })
+		assert.Equal(t, LazyType, lz.typ)
+		assert.Equal(t, String("body", "payload"), lz.resolve())
+
+		la := LazyAny("req", func() any { return map[string]int{"id": 1} })
+		assert.Equal(t, LazyType, la.typ)
+		assert.Equal(t, Any("req", map[string]int{"id": 1}), la.resolve())
+
+		// nested lazy
+		nested := Lazy("outer", func() Log { return lz })
+		assert.Equal(t, String("outer", "payload"), nested.resolve())
+
+		// not lazy
+		assert.Equal(t, Num("n", 1), Num("n", 1).resolve())
+	})
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should only evaluate when the level is enabled", func(t *testing.T) {
+			fileWr, fileObs := NewObserverWriter(WarnLevel, FILE)
+			nrWr, nrObs := NewObserverWriter(ErrorLevel, NEWRELIC)
+			wr := tc.fn(fileWr, nrWr)
+			wr.Init(time.Microsecond)
+
+			var called int
+			body := Lazy("body", func() Log {
+				called++
+				return String("body", "payload")
+			})
+			wr.Dbg("debug log", body)
+			wr.Inf("info log", body)
+			assert.Equal(t, 0, called)
+
+			wr.Wrn("warning log", body)
+			assert.Equal(t, 1, called)
+			require.Equal(t, 1, fileObs.Len())
+			assert.Equal(t, "payload", fileObs.All()[0].Get("body"))
+			assert.Equal(t, 0, nrObs.Len())
+		})
+	}
+}
@@ -11,6 +11,12 @@ type Logger interface {
 	// to the destination by the Log therefor this should be called at very
 	// last after other functions e.g. when gracefully shutting down server.
 	Flush(dur time.Duration)
+	// Enabled return true if at least one of the Writer will write logs at
+	// given lvl.
+	Enabled(lvl Level) bool
+	// WithOptions return a copy of the Logger after applying given
+	// LoggerOpt(s) e.g. WithCaller and WithStacktrace.
+	WithOptions(opts ...LoggerOpt) Logger
 	// With add given Log(s) as structured context.
 	With(pr ...Log) Logger
 	// Group create new group or namespace with given key and Log(s) as the
@@ -21,6 +27,8 @@ type Logger interface {
 	//  - https://pkg.go.dev/go.uber.org/zap#Namespace
 	//  - https://pkg.go.dev/golang.org/x/exp/slog#Group
 	Group(key string, pr ...Log) Logger
+	// Trc logs a message at TraceLevel.
+	Trc(msg string, pr ...Log)
 	// Dbg logs a message at DebugLevel.
 	Dbg(msg string, pr ...Log)
 	// Inf logs a message at InfoLevel.
@@ -29,4 +37,9 @@ type Logger interface {
 	Wrn(msg string, pr ...Log)
 	// Err logs a message at ErrorLevel.
 	Err(msg string, pr ...Log)
+	// Pnc logs a message at PanicLevel, flush every Writer then panics.
+	Pnc(msg string, pr ...Log)
+	// Ftl logs a message at FatalLevel, flush every Writer then calls
+	// os.Exit(1).
+	Ftl(msg string, pr ...Log)
 }
@@ -0,0 +1,125 @@
+package apilog
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exit is os.Exit by default. Replaced in tests.
+var exit = os.Exit
+
+// defaultExitTimeout default timeout used to flush every Writer before Pnc and
+// Ftl panics or exits.
+const defaultExitTimeout = 3 * time.Second
+
+// LoggerOpt options for Logger. Applied by Logger.WithOptions.
+type LoggerOpt func(*loggerOpts)
+
+// loggerOpts holds any optional behavior shared by each Logger implementation.
+type loggerOpts struct {
+	caller     bool
+	callerSkip int
+	stack      bool
+	stackLevel Level
+	exitDur    time.Duration
+	redactor   *redactor
+}
+
+// WithCaller annotate each log with the file name and line number of the
+// caller of Logger methods e.g. Dbg, Inf, Wrn and Err.
+func WithCaller() LoggerOpt {
+	return func(o *loggerOpts) {
+		o.caller = true
+	}
+}
+
+// WithCallerSkip increase the number of callers skipped by caller annotation.
+// Useful when Logger is wrapped by another function, so the reported caller
+// is the caller of that function instead.
+func WithCallerSkip(skip int) LoggerOpt {
+	return func(o *loggerOpts) {
+		o.callerSkip += skip
+	}
+}
+
+// WithStacktrace attach stack trace to each log at or above given lvl.
+func WithStacktrace(lvl Level) LoggerOpt {
+	return func(o *loggerOpts) {
+		o.stack = true
+		o.stackLevel = lvl
+	}
+}
+
+// WithExitTimeout set the timeout used to flush every Writer before Pnc and
+// Ftl panics or exits. Default to 3 seconds.
+func WithExitTimeout(dur time.Duration) LoggerOpt {
+	return func(o *loggerOpts) {
+		o.exitDur = dur
+	}
+}
+
+// apply return a copy of o after applying given options.
+func (o loggerOpts) apply(opts []LoggerOpt) loggerOpts {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// stackEnabled return true if stack trace should be attached to given lvl.
+func (o loggerOpts) stackEnabled(lvl Level) bool {
+	return o.stack && lvl >= o.stackLevel
+}
+
+// exitTimeout return the timeout used to flush every Writer before panics or
+// exits.
+func (o loggerOpts) exitTimeout() time.Duration {
+	if o.exitDur > 0 {
+		return o.exitDur
+	}
+	return defaultExitTimeout
+}
+
+// callerOf return the caller at given skip, formatted as 'dir/file.go:line'
+// the same way zap short caller encoder does.
+func callerOf(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "undefined"
+	}
+	// keep only the last directory and the file name
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(line)
+}
+
+// stacktraceOf return the stack trace starting from given skip, formatted the
+// same way zap stack trace does.
+func stacktraceOf(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+package apilog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCaller return the expected caller of this file at given line.
+func testCaller(line int) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Base(filepath.Dir(file)) + "/logger_opt_test.go:" + strconv.Itoa(line)
+}
+
+func TestCallerOf(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	assert.Equal(t, testCaller(line+1), callerOf(0))
+}
+
+func TestStacktraceOf(t *testing.T) {
+	st := stacktraceOf(0)
+	assert.Contains(t, st, "github.com/mdanialr/apilog.TestStacktraceOf\n\t")
+	assert.Contains(t, st, "logger_opt_test.go:")
+	assert.NotContains(t, st, "stacktraceOf")
+}
+
+func TestWithOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name+" should report the caller of the Logger methods", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer).WithOptions(WithCaller())
+			wr.Init(time.Microsecond)
+
+			_, _, line, _ := runtime.Caller(0)
+			wr.Inf("info log")
+			wr.With(String("hello", "world")).Wrn("warning log")
+
+			require.Equal(t, 2, obs.Len())
+			logs := obs.All()
+			assert.Equal(t, testCaller(line+1), logs[0].Get("caller"))
+			assert.Equal(t, testCaller(line+2), logs[1].Get("caller"))
+			assert.Nil(t, logs[0].Get("stacktrace"))
+		})
+
+		t.Run(tc.name+" should skip the extra callers", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer)
+			wr.Init(time.Microsecond)
+			wr = wr.WithOptions(WithCaller(), WithCallerSkip(1))
+
+			wrapper := func(msg string) { wr.Inf(msg) }
+			_, _, line, _ := runtime.Caller(0)
+			wrapper("info log")
+
+			require.Equal(t, 1, obs.Len())
+			assert.Equal(t, testCaller(line+1), obs.All()[0].Get("caller"))
+		})
+
+		t.Run(tc.name+" should attach stack trace at or above the given level", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer).WithOptions(WithStacktrace(ErrorLevel))
+			wr.Init(time.Microsecond)
+
+			wr.Wrn("warning log")
+			wr.Err("error log")
+
+			require.Equal(t, 2, obs.Len())
+			logs := obs.All()
+			assert.Nil(t, logs[0].Get("caller"))
+			assert.Nil(t, logs[0].Get("stacktrace"))
+			st, ok := logs[1].Get("stacktrace").(string)
+			require.True(t, ok)
+			assert.Regexp(t, `^github.com/mdanialr/apilog.TestWithOptions.func\d+\n\t.+/logger_opt_test.go:\d+\n`, st)
+		})
+
+		t.Run(tc.name+" should not affect the parent Logger", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			parent := tc.fn(writer)
+			parent.Init(time.Microsecond)
+			child := parent.WithOptions(WithCaller())
+
+			parent.Inf("parent")
+			child.Inf("child")
+
+			require.Equal(t, 2, obs.Len())
+			assert.Nil(t, obs.All()[0].Get("caller"))
+			assert.NotNil(t, obs.All()[1].Get("caller"))
+			// just to increase code coverage
+			assert.Equal(t, parent, parent.WithOptions())
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package apilog
 
 import (
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewNop(t *testing.T) {
@@ -19,14 +21,110 @@ func TestNewNop(t *testing.T) {
 		nl := NewNop()
 		assert.NotNil(t, nl)
 		assert.NotNil(t, nl.With())
+		assert.NotNil(t, nl.WithOptions())
+		assert.False(t, nl.Enabled(FatalLevel))
 		assert.NotNil(t, nl.Group("hello"))
 
 		// just run it, since its just do literary nothing
 		nl.Init(time.Microsecond)
 		nl.Flush(time.Microsecond)
+		nl.Trc("")
 		nl.Dbg("")
 		nl.Inf("")
 		nl.Wrn("")
 		nl.Err("")
 	})
+
+	t.Run("Should still panics and exits", func(t *testing.T) {
+		var code int
+		exit = func(c int) { code = c }
+		defer func() { exit = os.Exit }()
+
+		nl := NewNop()
+		assert.PanicsWithValue(t, "panic", func() { nl.Pnc("panic") })
+		nl.Ftl("fatal")
+		assert.Equal(t, 1, code)
+	})
+}
+
+// flushedWriter Writer implementer that keep track whether Flush is called.
+type flushedWriter struct {
+	*ObservedLog
+	flushed bool
+}
+
+func (f *flushedWriter) Flush(_ time.Duration) { f.flushed = true }
+
+func TestTerminalLevels(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name+" Pnc should flush every Writer then panics", func(t *testing.T) {
+			_, obs := NewObserverWriter(DebugLevel, FILE)
+			fw := &flushedWriter{ObservedLog: obs}
+			wr := tc.fn(fw).WithOptions(WithExitTimeout(time.Second))
+			wr.Init(time.Microsecond)
+
+			assert.PanicsWithValue(t, "panic log", func() {
+				wr.Pnc("panic log", String("hello", "world"))
+			})
+			assert.True(t, fw.flushed)
+			require.Equal(t, 1, obs.Len())
+			assert.True(t, obs.All()[0].EqualLevel(PanicLevel))
+			assert.Equal(t, "world", obs.All()[0].Get("hello"))
+		})
+
+		t.Run(tc.name+" Ftl should flush every Writer then exits", func(t *testing.T) {
+			var code int
+			exit = func(c int) { code = c }
+			defer func() { exit = os.Exit }()
+
+			_, obs := NewObserverWriter(DebugLevel, FILE)
+			fw := &flushedWriter{ObservedLog: obs}
+			wr := tc.fn(fw)
+			wr.Init(time.Microsecond)
+
+			wr.Ftl("fatal log")
+			assert.Equal(t, 1, code)
+			assert.True(t, fw.flushed)
+			require.Equal(t, 1, obs.Len())
+			assert.True(t, obs.All()[0].EqualLevel(FatalLevel))
+			assert.True(t, obs.All()[0].EqualMsg("fatal log"))
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name+" should consult every Writer", func(t *testing.T) {
+			fileWr, _ := NewObserverWriter(WarnLevel, FILE)
+			cnsWr, _ := NewObserverWriter(InfoLevel, CONSOLE)
+			wr := tc.fn(fileWr, cnsWr)
+			assert.False(t, wr.Enabled(ErrorLevel), "not initialized yet")
+
+			wr.Init(time.Microsecond)
+			assert.False(t, wr.Enabled(TraceLevel))
+			assert.False(t, wr.Enabled(DebugLevel))
+			assert.True(t, wr.Enabled(InfoLevel))
+			assert.True(t, wr.Enabled(ErrorLevel))
+
+			// should follow the runtime level as well
+			cnsWr.(LevelAdjuster).AtomicLevel().SetLevel(TraceLevel)
+			assert.True(t, wr.Enabled(TraceLevel))
+		})
+	}
 }
@@ -0,0 +1,257 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// lokiPushPath path of loki push API.
+const lokiPushPath = "/loki/api/v1/push"
+
+// NewLokiWriter return Writer implementer that push logs in batches to
+// grafana loki by given Config and set given lvl as the log Level. Logs are
+// grouped into streams by the labels extracted from their fields. Batching,
+// retry and error handling are configured using the same options as
+// NewHTTPWriter e.g. WithHTTPBatchSize, except the URL.
+func NewLokiWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	l := &lokiEncoder{cnf: cnf.loki}
+	// set default value
+	if len(l.cnf.labels) == 0 {
+		l.cnf.labels = []string{"level"}
+	}
+
+	hc := cnf.http
+	hc.url = endpointURL(cnf.loki.url, "http://localhost:3100", lokiPushPath)
+	hc.header = hc.header.Clone()
+	if cnf.loki.tenant != "" {
+		if hc.header == nil {
+			hc.header = make(http.Header)
+		}
+		hc.header.Set("X-Scope-OrgID", cnf.loki.tenant)
+	}
+	if cnf.loki.protobuf {
+		// snappy compressed by the encoder instead
+		hc.contentType, hc.noGzip = "application/x-protobuf", true
+		return newHTTPBatcher(LOKI, lvl, hc, l.encodeProto)
+	}
+	hc.contentType = "application/json"
+	return newHTTPBatcher(LOKI, lvl, hc, l.encodeJSON)
+}
+
+// lokiEncoder encode the batch as loki push request.
+type lokiEncoder struct {
+	cnf LokiConfig
+}
+
+// lokiStream logs that share the same labels.
+type lokiStream struct {
+	labels  map[string]string
+	entries []lokiEntry
+}
+
+// lokiEntry a single log line along with its timestamp.
+type lokiEntry struct {
+	t    time.Time
+	line []byte
+}
+
+// streams group given entries into streams by their labels, keyed by the
+// labels in prometheus format, sorted by the key.
+func (l *lokiEncoder) streams(entries [][]byte) ([]string, map[string]*lokiStream) {
+	var keys []string
+	streams := make(map[string]*lokiStream)
+	for _, e := range entries {
+		var fields map[string]any
+		_ = json.Unmarshal(e, &fields)
+
+		labels := l.labelsOf(fields)
+		key := lokiLabelsString(labels)
+		st, ok := streams[key]
+		if !ok {
+			st = &lokiStream{labels: labels}
+			streams[key] = st
+			keys = append(keys, key)
+		}
+		st.entries = append(st.entries, lokiEntry{t: timeOf(fields), line: e})
+	}
+	sort.Strings(keys)
+	return keys, streams
+}
+
+// labelsOf return the static labels and the labels extracted from given
+// fields. Nested field is extracted by its dot separated path e.g.
+// 'context.app' and named using underscore instead e.g. 'context_app'.
+func (l *lokiEncoder) labelsOf(fields map[string]any) map[string]string {
+	labels := make(map[string]string, len(l.cnf.static)+len(l.cnf.labels))
+	for k, v := range l.cnf.static {
+		labels[lokiLabelName(k)] = v
+	}
+	for _, path := range l.cnf.labels {
+		if v, ok := fieldOf(fields, path); ok {
+			labels[lokiLabelName(path)] = v
+		}
+	}
+	// loki require at least one label
+	if len(labels) == 0 {
+		labels["service_name"] = filepath.Base(os.Args[0])
+	}
+	return labels
+}
+
+// encodeJSON encode given entries as loki push request in JSON format.
+func (l *lokiEncoder) encodeJSON(entries [][]byte) ([]byte, error) {
+	keys, streams := l.streams(entries)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"streams":[`)
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		st := streams[k]
+		labels, err := json.Marshal(st.labels)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`{"stream":`)
+		buf.Write(labels)
+		buf.WriteString(`,"values":[`)
+		for j, e := range st.entries {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			line, err := json.Marshal(string(e.line))
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(`["`)
+			buf.WriteString(strconv.FormatInt(e.t.UnixNano(), 10))
+			buf.WriteString(`",`)
+			buf.Write(line)
+			buf.WriteByte(']')
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes(), nil
+}
+
+// encodeProto encode given entries as loki push request in snappy compressed
+// protobuf format.
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func (l *lokiEncoder) encodeProto(entries [][]byte) ([]byte, error) {
+	keys, streams := l.streams(entries)
+
+	var req []byte
+	for _, k := range keys {
+		var st []byte
+		st = protowire.AppendTag(st, 1, protowire.BytesType)
+		st = protowire.AppendString(st, k)
+		for _, e := range streams[k].entries {
+			var ts []byte
+			ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.t.Unix()))
+			ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.t.Nanosecond()))
+
+			var en []byte
+			en = protowire.AppendTag(en, 1, protowire.BytesType)
+			en = protowire.AppendBytes(en, ts)
+			en = protowire.AppendTag(en, 2, protowire.BytesType)
+			en = protowire.AppendBytes(en, e.line)
+
+			st = protowire.AppendTag(st, 2, protowire.BytesType)
+			st = protowire.AppendBytes(st, en)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, st)
+	}
+	return snappy.Encode(nil, req), nil
+}
+
+// lokiLabelsString return given labels in prometheus format sorted by the
+// label name e.g. '{app="api", level="INFO"}'.
+func lokiLabelsString(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range names {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[k]))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// lokiLabelName return given name after replacing characters that are not
+// allowed in loki label name with underscore.
+func lokiLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// fieldOf return the value of the field at given dot separated path as string.
+func fieldOf(fields map[string]any, path string) (string, bool) {
+	var v any = fields
+	for _, k := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[k]; !ok {
+			return "", false
+		}
+	}
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case nil:
+		return "", false
+	case map[string]any, []any:
+		b, _ := json.Marshal(val)
+		return string(b), true
+	}
+	return fmt.Sprint(v), true
+}
+
+// timeOf return the time of given log fields, or now if not found.
+func timeOf(fields map[string]any) time.Time {
+	if s, ok := fields["time"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
@@ -0,0 +1,220 @@
+package apilog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// lokiTestStream stream decoded from loki push request.
+type lokiTestStream struct {
+	labels string
+	ts     []time.Time
+	lines  []string
+}
+
+// decodeLokiProto decode given snappy compressed protobuf loki push request.
+func decodeLokiProto(t *testing.T, b []byte) []lokiTestStream {
+	b, err := snappy.Decode(nil, b)
+	require.NoError(t, err)
+
+	// fields return every field of given message by its number
+	fields := func(b []byte) map[protowire.Number][][]byte {
+		out := make(map[protowire.Number][][]byte)
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			switch typ {
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(b)
+				require.GreaterOrEqual(t, n, 0)
+				out[num] = append(out[num], v)
+				b = b[n:]
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(b)
+				require.GreaterOrEqual(t, n, 0)
+				out[num] = append(out[num], protowire.AppendVarint(nil, v))
+				b = b[n:]
+			default:
+				t.Fatalf("unexpected wire type %d", typ)
+			}
+		}
+		return out
+	}
+	varint := func(b []byte) int64 {
+		v, _ := protowire.ConsumeVarint(b)
+		return int64(v)
+	}
+
+	var streams []lokiTestStream
+	for _, st := range fields(b)[1] {
+		sf := fields(st)
+		s := lokiTestStream{labels: string(sf[1][0])}
+		for _, en := range sf[2] {
+			ef := fields(en)
+			ts := fields(ef[1][0])
+			var nanos int64
+			if len(ts[2]) > 0 {
+				nanos = varint(ts[2][0])
+			}
+			s.ts = append(s.ts, time.Unix(varint(ts[1][0]), nanos))
+			s.lines = append(s.lines, string(ef[2][0]))
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+// decodeLokiJSON decode given JSON loki push request.
+func decodeLokiJSON(t *testing.T, b []byte) []lokiTestStream {
+	var req struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal(b, &req))
+
+	var streams []lokiTestStream
+	for _, st := range req.Streams {
+		s := lokiTestStream{labels: lokiLabelsString(st.Stream)}
+		for _, v := range st.Values {
+			var ns int64
+			require.NoError(t, json.Unmarshal([]byte(v[0]), &ns))
+			s.ts = append(s.ts, time.Unix(0, ns))
+			s.lines = append(s.lines, v[1])
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+func TestLokiLabels(t *testing.T) {
+	t.Run("Should replace invalid characters in label name", func(t *testing.T) {
+		assert.Equal(t, "context_app", lokiLabelName("context.app"))
+		assert.Equal(t, "_st", lokiLabelName("1st"))
+		assert.Equal(t, "a_b_c9", lokiLabelName("a-b c9"))
+	})
+
+	t.Run("Should format labels in prometheus format sorted by name", func(t *testing.T) {
+		labels := map[string]string{"level": "INFO", "app": `my "api"`}
+		assert.Equal(t, `{app="my \"api\"", level="INFO"}`, lokiLabelsString(labels))
+	})
+
+	t.Run("Should extract labels from the fields including the nested ones", func(t *testing.T) {
+		l := &lokiEncoder{cnf: LokiConfig{
+			labels: []string{"level", "context.app", "code", "missing"},
+			static: map[string]string{"env": "prod"},
+		}}
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal([]byte(`{"level":"INFO","code":200,"context":{"app":"api"}}`), &fields))
+		assert.Equal(t, map[string]string{
+			"env":         "prod",
+			"level":       "INFO",
+			"context_app": "api",
+			"code":        "200",
+		}, l.labelsOf(fields))
+	})
+
+	t.Run("Should fallback to service name if there is no label", func(t *testing.T) {
+		l := &lokiEncoder{}
+		assert.Contains(t, l.labelsOf(nil), "service_name")
+	})
+}
+
+func TestNewLokiWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewLokiWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &httpBatcher{}, wr.Writer())
+		assert.Equal(t, LOKI, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "http://localhost:3100/loki/api/v1/push", h.cnf.url)
+		assert.Equal(t, "application/json", h.cnf.contentType)
+		assert.False(t, h.cnf.noGzip)
+	})
+}
+
+func TestLokiWriter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		protobuf    bool
+		contentType string
+		decode      func(*testing.T, []byte) []lokiTestStream
+	}{
+		{name: "JSON", contentType: "application/json", decode: decodeLokiJSON},
+		{name: "Protobuf", protobuf: true, contentType: "application/x-protobuf", decode: decodeLokiProto},
+	}
+	loggers := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		for _, lg := range loggers {
+			t.Run(lg.name+" should push logs grouped into streams by labels using "+tc.name, func(t *testing.T) {
+				srv, ch := newHTTPServer(t, nil)
+				lk := NewLokiWriter(InfoLevel, NewConfig(
+					WithLokiURL(srv.URL),
+					WithLokiTenant("team-a"),
+					WithLokiLabels("level", "app"),
+					WithLokiStaticLabel("env", "prod"),
+					WithLokiProtobuf(tc.protobuf),
+				))
+				wr := lg.fn(lk)
+				wr.Init(time.Microsecond)
+
+				start := time.Now().Truncate(time.Second)
+				wr.Inf("first", String("app", "api"))
+				wr.Err("second", String("app", "api"))
+				wr.Inf("third", String("app", "api"))
+				wr.Inf("fourth", String("app", "worker"))
+				wr.Flush(time.Second)
+
+				req := receiveRequest(t, ch)
+				assert.Equal(t, "team-a", req.header.Get("X-Scope-OrgID"))
+				assert.Equal(t, tc.contentType, req.header.Get("Content-Type"))
+
+				streams := tc.decode(t, req.body)
+				require.Len(t, streams, 3)
+				expect := []struct {
+					labels string
+					msgs   []string
+				}{
+					{labels: `{app="api", env="prod", level="ERROR"}`, msgs: []string{"second"}},
+					{labels: `{app="api", env="prod", level="INFO"}`, msgs: []string{"first", "third"}},
+					{labels: `{app="worker", env="prod", level="INFO"}`, msgs: []string{"fourth"}},
+				}
+				for i, e := range expect {
+					assert.Equal(t, e.labels, streams[i].labels)
+					require.Len(t, streams[i].lines, len(e.msgs))
+					for j, msg := range e.msgs {
+						var line map[string]any
+						require.NoError(t, json.Unmarshal([]byte(streams[i].lines[j]), &line))
+						assert.Equal(t, msg, line["msg"])
+						// the timestamp is taken from the log itself
+						assert.False(t, streams[i].ts[j].Before(start))
+						assert.WithinDuration(t, time.Now(), streams[i].ts[j], 2*time.Second)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTimeOf(t *testing.T) {
+	at := time.Date(2026, 10, 17, 7, 59, 13, 123456789, time.UTC)
+	assert.True(t, at.Equal(timeOf(map[string]any{"time": "2026-10-17T07:59:13.123456789Z"})))
+	assert.WithinDuration(t, time.Now(), timeOf(map[string]any{"time": "invalid"}), time.Second)
+	assert.WithinDuration(t, time.Now(), timeOf(nil), time.Second)
+}
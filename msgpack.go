@@ -0,0 +1,286 @@
+package apilog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// appendMsgpack append the MessagePack encoding of given v to given b. Only
+// support the types produced by decoding JSON using json.Number, along with
+// int, int64, uint64, []byte and time.Time as EventTime extension used by the
+// fluentd forward protocol.
+func appendMsgpack(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if val {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(val))
+	case int64:
+		return appendMsgpackInt(b, val)
+	case uint64:
+		if val <= math.MaxInt64 {
+			return appendMsgpackInt(b, int64(val))
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), val)
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(val))
+	case json.Number:
+		if i, err := strconv.ParseInt(val.String(), 10, 64); err == nil {
+			return appendMsgpackInt(b, i)
+		}
+		if u, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return appendMsgpack(b, u)
+		}
+		f, _ := val.Float64()
+		return appendMsgpack(b, f)
+	case string:
+		return append(appendMsgpackHeader(b, len(val), 0xa0, 31, 0xd9), val...)
+	case []byte:
+		return append(appendMsgpackHeader(b, len(val), 0, 0, 0xc4), val...)
+	case time.Time:
+		// EventTime: fixext8 of type 0 holding seconds and nanoseconds
+		b = append(b, 0xd7, 0x00)
+		b = binary.BigEndian.AppendUint32(b, uint32(val.Unix()))
+		return binary.BigEndian.AppendUint32(b, uint32(val.Nanosecond()))
+	case []any:
+		b = appendMsgpackHeader(b, len(val), 0x90, 15, 0xdc)
+		for _, e := range val {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgpackHeader(b, len(val), 0x80, 15, 0xde)
+		for _, k := range keys {
+			b = appendMsgpack(b, k)
+			b = appendMsgpack(b, val[k])
+		}
+		return b
+	}
+	return appendMsgpack(b, fmt.Sprint(v))
+}
+
+// appendMsgpackInt append the smallest MessagePack encoding of given i.
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 127:
+		return append(b, byte(i))
+	case i < 0 && i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// appendMsgpackHeader append the header of str, bin, array or map of given
+// length. fix is the prefix of the fix format that can hold up to fixMax
+// length, zero if there is none. code is the prefix of the 8-bit format for
+// str and bin, or 16-bit format for array and map. The next formats follow
+// right after.
+func appendMsgpackHeader(b []byte, n int, fix byte, fixMax int, code byte) []byte {
+	switch {
+	case fix != 0 && n <= fixMax:
+		return append(b, fix|byte(n))
+	case (code == 0xd9 || code == 0xc4) && n <= math.MaxUint8:
+		return append(b, code, byte(n))
+	case (code == 0xd9 || code == 0xc4) && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code+1), uint16(n))
+	case code == 0xd9 || code == 0xc4:
+		return binary.BigEndian.AppendUint32(append(b, code+2), uint32(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code+1), uint32(n))
+}
+
+// maxMsgpackLen maximum length of str, bin, array or map accepted by
+// readMsgpack, so malformed input can not exhaust the memory.
+const maxMsgpackLen = 64 << 20
+
+// maxMsgpackPrealloc maximum capacity preallocated from the length header of
+// str, bin, array or map. Bigger value grows as it is read, so a header that
+// lies about its length can not allocate more than what is actually sent.
+const maxMsgpackPrealloc = 1 << 10
+
+// readMsgpack read a single MessagePack value from given r. Integers are
+// decoded as int64 or uint64, str as string, bin as []byte, EventTime as
+// time.Time and other extensions as []byte.
+func readMsgpack(r io.ByteReader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return readMsgpackMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return readMsgpackArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		b, err := readMsgpackBytes(r, int(c&0x1f))
+		return string(b), err
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xca:
+		n, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := readMsgpackUint(r, 1<<(c-0xcc))
+		if c == 0xcf && n > math.MaxInt64 {
+			return n, err
+		}
+		return int64(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := readMsgpackUint(r, size)
+		// sign extend
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(c-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackUint(r, 1<<(c-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, int(n))
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		b, err := readMsgpackBytes(r, int(n))
+		return string(b), err
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n))
+	}
+	return nil, fmt.Errorf("unsupported msgpack format 0x%x", c)
+}
+
+// readMsgpackUint read big endian unsigned integer of given size in bytes.
+func readMsgpackUint(r io.ByteReader, size int) (uint64, error) {
+	var n uint64
+	for i := 0; i < size; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func readMsgpackBytes(r io.ByteReader, n int) ([]byte, error) {
+	if n > maxMsgpackLen {
+		return nil, errors.New("msgpack value is too large")
+	}
+	b := make([]byte, 0, min(n, maxMsgpackPrealloc))
+	for i := 0; i < n; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
+
+func readMsgpackArray(r io.ByteReader, n int) ([]any, error) {
+	if n > maxMsgpackLen {
+		return nil, errors.New("msgpack array is too large")
+	}
+	arr := make([]any, 0, min(n, maxMsgpackPrealloc))
+	for i := 0; i < n; i++ {
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func readMsgpackMap(r io.ByteReader, n int) (map[string]any, error) {
+	if n > maxMsgpackLen {
+		return nil, errors.New("msgpack map is too large")
+	}
+	m := make(map[string]any, min(n, maxMsgpackPrealloc))
+	for i := 0; i < n; i++ {
+		k, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
+
+func readMsgpackExt(r io.ByteReader, n int) (any, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := readMsgpackBytes(r, n)
+	if err != nil {
+		return nil, err
+	}
+	if typ == 0 && n == 8 {
+		return time.Unix(int64(binary.BigEndian.Uint32(b[:4])), int64(binary.BigEndian.Uint32(b[4:]))), nil
+	}
+	return b, nil
+}
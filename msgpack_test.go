@@ -0,0 +1,97 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMsgpack(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sample any
+		expect []byte
+	}{
+		{name: "Given nil should be nil", sample: nil, expect: []byte{0xc0}},
+		{name: "Given true should be true", sample: true, expect: []byte{0xc3}},
+		{name: "Given small positive int should be positive fixint", sample: 7, expect: []byte{0x07}},
+		{name: "Given small negative int should be negative fixint", sample: -1, expect: []byte{0xff}},
+		{name: "Given int8 should be int 8", sample: -100, expect: []byte{0xd0, 0x9c}},
+		{name: "Given int16 should be int 16", sample: 300, expect: []byte{0xd1, 0x01, 0x2c}},
+		{name: "Given int32 should be int 32", sample: int64(70000), expect: []byte{0xd2, 0x00, 0x01, 0x11, 0x70}},
+		{name: "Given integer json number should be int", sample: json.Number("42"), expect: []byte{0x2a}},
+		{name: "Given float json number should be float 64", sample: json.Number("1.5"), expect: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "Given short string should be fixstr", sample: "ab", expect: []byte{0xa2, 'a', 'b'}},
+		{name: "Given bytes should be bin 8", sample: []byte("ab"), expect: []byte{0xc4, 0x02, 'a', 'b'}},
+		{name: "Given time should be EventTime", sample: time.Unix(1, 2), expect: []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2}},
+		{name: "Given array should be fixarray", sample: []any{1, "a"}, expect: []byte{0x92, 0x01, 0xa1, 'a'}},
+		{name: "Given map should be fixmap with sorted keys", sample: map[string]any{"b": 2, "a": 1}, expect: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, appendMsgpack(nil, tc.sample))
+		})
+	}
+}
+
+func TestReadMsgpack(t *testing.T) {
+	t.Run("Should decode back what is encoded", func(t *testing.T) {
+		long := strings.Repeat("x", 70000)
+		sample := map[string]any{
+			"nil":    nil,
+			"bool":   false,
+			"int":    int64(-70000),
+			"big":    int64(math.MaxInt64),
+			"uint":   uint64(math.MaxUint64),
+			"float":  1.25,
+			"str":    "hello",
+			"long":   long,
+			"bin":    []byte{1, 2, 3},
+			"time":   time.Unix(1724806753, 259000000),
+			"array":  []any{int64(1), "two"},
+			"nested": map[string]any{"key": "val"},
+		}
+		b := appendMsgpack(nil, sample)
+
+		v, err := readMsgpack(bytes.NewReader(b))
+		require.NoError(t, err)
+		m := v.(map[string]any)
+		assert.True(t, sample["time"].(time.Time).Equal(m["time"].(time.Time)))
+		delete(m, "time")
+		delete(sample, "time")
+		assert.Equal(t, sample, m)
+	})
+
+	t.Run("Given truncated input should return error", func(t *testing.T) {
+		b := appendMsgpack(nil, "hello")
+		_, err := readMsgpack(bytes.NewReader(b[:3]))
+		assert.Error(t, err)
+	})
+
+	t.Run("Given huge length header followed by EOF should not preallocate the length", func(t *testing.T) {
+		for _, code := range []byte{0xc6, 0xdb, 0xdd, 0xdf} {
+			b := binary.BigEndian.AppendUint32([]byte{code}, maxMsgpackLen)
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := readMsgpack(bytes.NewReader(b))
+			runtime.ReadMemStats(&after)
+
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+		}
+	})
+
+	t.Run("Given unsupported format should return error", func(t *testing.T) {
+		_, err := readMsgpack(bytes.NewReader([]byte{0xc1}))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,305 @@
+package apilog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// networkTimeout maximum time to connect and to write the buffered logs.
+const networkTimeout = 10 * time.Second
+
+// errBufferFull error returned by NETWORK Writer when its buffer is full.
+var errBufferFull = errors.New("network buffer is full, log is dropped")
+
+// NewNetworkWriter return Writer implementer that send logs as newline
+// delimited JSON to tcp, udp or unix socket e.g. vector or logstash by given
+// Config and set given lvl as the log Level. Logs are buffered up to the
+// buffer size and sent in the background, reconnecting with exponential
+// backoff while disconnected. Write return error once the buffer is full.
+// Its connection state can be retrieved using ConnStateOf.
+func NewNetworkWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &networkOutput{
+		lvl:    NewAtomicLevel(lvl),
+		cnf:    cnf.network,
+		notify: make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	// set default value
+	if n.cnf.network == "" {
+		n.cnf.network = "tcp"
+	}
+	if n.cnf.addr == "" {
+		n.cnf.addr = "localhost:9000"
+	}
+	if n.cnf.buffer <= 0 {
+		n.cnf.buffer = 1 << 20
+	}
+	if n.cnf.backoff <= 0 {
+		n.cnf.backoff = 500 * time.Millisecond
+	}
+	go n.run()
+	return n
+}
+
+type networkOutput struct {
+	lvl   *AtomicLevel
+	cnf   NetworkConfig
+	state atomic.Int32
+
+	mu     sync.Mutex // guard buf, size and closed
+	buf    [][]byte
+	size   int
+	closed bool
+	notify chan struct{}
+
+	cmu  sync.Mutex // guard conn
+	conn net.Conn
+
+	once   sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+	quit   chan struct{}
+	done   chan struct{}
+}
+
+// Write implement io.Writer by adding given log terminated by newline to the
+// buffer, so it's sent in the background.
+func (n *networkOutput) Write(p []byte) (int, error) {
+	e := bytes.TrimSpace(p)
+	if len(e) == 0 {
+		return len(p), nil
+	}
+	// zap and slog reuse their buffer after Write returns
+	e = append(bytes.Clone(e), '\n')
+
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		// already flushed, so just send it directly
+		if _, err := n.send(context.Background(), [][]byte{e}); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if n.size+len(e) > n.cnf.buffer {
+		n.mu.Unlock()
+		return 0, errBufferFull
+	}
+	n.buf = append(n.buf, e)
+	n.size += len(e)
+	n.mu.Unlock()
+
+	select {
+	case n.notify <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// run send the buffered logs whenever there is any until Flush is called,
+// then send whatever left.
+func (n *networkOutput) run() {
+	defer close(n.done)
+	for {
+		select {
+		case <-n.notify:
+			n.drain()
+		case <-n.quit:
+			n.drain()
+			n.mu.Lock()
+			left := len(n.buf)
+			n.buf, n.size = nil, 0
+			n.mu.Unlock()
+			if left > 0 {
+				n.report(fmt.Errorf("failed to send %d logs: %w", left, n.ctx.Err()))
+			}
+			return
+		}
+	}
+}
+
+// drain send the buffered logs until the buffer is empty, reconnecting with
+// exponential backoff on failure until ctx is done. Logs are only removed
+// from the buffer once they are sent, so the ones that are already sent
+// before the failure are not resent.
+func (n *networkOutput) drain() {
+	for attempt := 0; ; {
+		n.mu.Lock()
+		entries := n.buf
+		n.mu.Unlock()
+		if len(entries) == 0 {
+			return
+		}
+
+		sent, err := n.send(n.ctx, entries)
+		n.mu.Lock()
+		for _, e := range entries[:sent] {
+			n.size -= len(e)
+		}
+		if n.buf = n.buf[sent:]; len(n.buf) == 0 {
+			n.buf = nil // release the underlying array
+		}
+		n.mu.Unlock()
+
+		if err != nil {
+			n.report(err)
+			t := time.NewTimer(backoffOf(n.cnf.backoff, attempt))
+			select {
+			case <-t.C:
+			case <-n.ctx.Done():
+				t.Stop()
+				return
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+	}
+}
+
+// send write given entries to the current connection, connecting first if
+// not connected yet, then return the number of entries that are completely
+// written. Close the connection on failure, so it reconnects on the next
+// attempt. The write is aborted once ctx is done or networkTimeout is passed.
+func (n *networkOutput) send(ctx context.Context, entries [][]byte) (int, error) {
+	n.cmu.Lock()
+	defer n.cmu.Unlock()
+
+	if n.conn == nil {
+		if err := n.dial(ctx, networkTimeout); err != nil {
+			return 0, fmt.Errorf("failed to connect: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(networkTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn := n.conn
+	_ = conn.SetWriteDeadline(deadline)
+	// unblock the write once ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetWriteDeadline(time.Now()) })
+	defer stop()
+
+	var sent int
+	var err error
+	if n.stream() {
+		var written int
+		written, err = conn.Write(bytes.Join(entries, nil))
+		for _, e := range entries {
+			if written -= len(e); written < 0 {
+				break
+			}
+			sent++
+		}
+	} else {
+		// each log is sent as a datagram
+		for _, e := range entries {
+			if _, err = conn.Write(e); err != nil {
+				break
+			}
+			sent++
+		}
+	}
+	if err != nil {
+		n.close()
+		return sent, fmt.Errorf("failed to write: %w", err)
+	}
+	return sent, nil
+}
+
+// stream return true if the configured network is stream based.
+func (n *networkOutput) stream() bool {
+	switch n.cnf.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// dial connect to the configured address within given timeout, using TLS if
+// set.
+func (n *networkOutput) dial(ctx context.Context, timeout time.Duration) error {
+	d := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if n.cnf.tls != nil {
+		td := &tls.Dialer{NetDialer: d, Config: n.cnf.tls}
+		conn, err = td.DialContext(ctx, n.cnf.network, n.cnf.addr)
+	} else {
+		conn, err = d.DialContext(ctx, n.cnf.network, n.cnf.addr)
+	}
+	if err != nil {
+		return err
+	}
+	n.conn = conn
+	n.state.Store(int32(Connected))
+	return nil
+}
+
+// close close the current connection if any.
+func (n *networkOutput) close() {
+	if n.conn != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+	}
+	n.state.Store(int32(Disconnected))
+}
+
+// report report given err to the error handler if any.
+func (n *networkOutput) report(err error) {
+	if n.cnf.onError != nil {
+		n.cnf.onError(err)
+	}
+}
+
+func (n *networkOutput) Writer() io.Writer         { return n }
+func (n *networkOutput) Output() Output            { return NETWORK }
+func (n *networkOutput) Level() Level              { return n.lvl.Level() }
+func (n *networkOutput) AtomicLevel() *AtomicLevel { return n.lvl }
+func (n *networkOutput) ConnState() ConnState      { return ConnState(n.state.Load()) }
+
+// Wait try to connect within given dur.
+func (n *networkOutput) Wait(dur time.Duration) {
+	n.cmu.Lock()
+	defer n.cmu.Unlock()
+
+	if n.conn == nil && dur > 0 {
+		_ = n.dial(context.Background(), dur)
+	}
+}
+
+// Flush send the buffered logs within given dur, then close the connection.
+// The remaining logs are reported to the error handler as dropped.
+func (n *networkOutput) Flush(dur time.Duration) {
+	n.once.Do(func() {
+		n.mu.Lock()
+		n.closed = true
+		n.mu.Unlock()
+		close(n.quit)
+	})
+
+	t := time.AfterFunc(dur, n.cancel)
+	defer t.Stop()
+	<-n.done
+
+	n.cmu.Lock()
+	defer n.cmu.Unlock()
+	n.close()
+}
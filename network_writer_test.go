@@ -0,0 +1,349 @@
+package apilog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineServer accept connections from given ln then send each received line
+// to the returned channel.
+func lineServer(t *testing.T, ln net.Listener) <-chan string {
+	ch := make(chan string, 100)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					ch <- sc.Text()
+				}
+			}()
+		}
+	}()
+	return ch
+}
+
+func TestNewNetworkWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewNetworkWriter(WarnLevel, nil)
+		defer wr.Flush(0)
+		assert.IsType(t, &networkOutput{}, wr.Writer())
+		assert.Equal(t, NETWORK, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		n := wr.(*networkOutput)
+		assert.Equal(t, "tcp", n.cnf.network)
+		assert.Equal(t, "localhost:9000", n.cnf.addr)
+		assert.Equal(t, 1<<20, n.cnf.buffer)
+		assert.Equal(t, 500*time.Millisecond, n.cnf.backoff)
+		assert.Equal(t, Disconnected, n.ConnState())
+	})
+}
+
+func TestNetworkWriter(t *testing.T) {
+	srvTLS, clTLS := testTLSConfig(t)
+	// require client certificate signed by the same self-signed certificate
+	srvTLS.ClientAuth = tls.RequireAndVerifyClientCert
+	srvTLS.ClientCAs = clTLS.RootCAs
+	clTLS.Certificates = srvTLS.Certificates
+
+	sockDir, err := os.MkdirTemp("", "network")
+	require.NoError(t, err)
+	defer os.RemoveAll(sockDir)
+
+	testCases := []struct {
+		name    string
+		network string
+		listen  func() (net.Listener, string)
+		opts    []ConfigOpt
+	}{
+		{
+			name:    "tcp",
+			network: "tcp",
+			listen: func() (net.Listener, string) {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				return ln, ln.Addr().String()
+			},
+		},
+		{
+			name:    "tls with client certificate",
+			network: "tcp",
+			listen: func() (net.Listener, string) {
+				ln, err := tls.Listen("tcp", "127.0.0.1:0", srvTLS)
+				require.NoError(t, err)
+				return ln, ln.Addr().String()
+			},
+			opts: []ConfigOpt{WithNetworkTLS(clTLS)},
+		},
+		{
+			name:    "unix",
+			network: "unix",
+			listen: func() (net.Listener, string) {
+				sock := filepath.Join(sockDir, "unix.sock")
+				ln, err := net.Listen("unix", sock)
+				require.NoError(t, err)
+				return ln, sock
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("Given "+tc.name+" should send each log as newline delimited JSON", func(t *testing.T) {
+			ln, addr := tc.listen()
+			ch := lineServer(t, ln)
+
+			wr := NewNetworkWriter(InfoLevel, NewConfig(append(tc.opts, WithNetworkAddress(tc.network, addr))...))
+			wr.Wait(time.Second)
+			state, ok := ConnStateOf(wr)
+			assert.True(t, ok)
+			assert.Equal(t, Connected, state)
+
+			_, err := wr.Writer().Write([]byte(`{"msg":"first"}` + "\n"))
+			require.NoError(t, err)
+			_, err = wr.Writer().Write([]byte(`{"msg":"second"}`))
+			require.NoError(t, err)
+			assert.Equal(t, `{"msg":"first"}`, receive(t, ch))
+			assert.Equal(t, `{"msg":"second"}`, receive(t, ch))
+
+			wr.Flush(time.Second)
+			assert.Equal(t, Disconnected, wr.(Connector).ConnState())
+		})
+	}
+
+	t.Run("Given udp should send each log as a datagram", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		wr := NewNetworkWriter(InfoLevel, NewConfig(WithNetworkAddress("udp", conn.LocalAddr().String())))
+		defer wr.Flush(time.Second)
+		_, _ = wr.Writer().Write([]byte(`{"msg":"first"}`))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"second"}`))
+
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		for _, msg := range []string{"first", "second"} {
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			assert.Equal(t, `{"msg":"`+msg+`"}`+"\n", string(buf[:n]))
+		}
+	})
+
+	t.Run("Given collector that is not available yet should buffer the logs until connected", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		var mu sync.Mutex
+		var reported []error
+		wr := NewNetworkWriter(InfoLevel, NewConfig(
+			WithNetworkAddress("tcp", addr),
+			WithNetworkBackoff(10*time.Millisecond),
+			WithNetworkErrorHandler(func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, err)
+			}),
+		))
+		defer wr.Flush(time.Second)
+		_, err = wr.Writer().Write([]byte(`{"msg":"first"}`))
+		require.NoError(t, err)
+		_, err = wr.Writer().Write([]byte(`{"msg":"second"}`))
+		require.NoError(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, Disconnected, wr.(Connector).ConnState())
+		mu.Lock()
+		require.NotEmpty(t, reported)
+		assert.True(t, strings.HasPrefix(reported[0].Error(), "failed to connect"))
+		mu.Unlock()
+
+		ln, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		ch := lineServer(t, ln)
+		assert.Equal(t, `{"msg":"first"}`, receive(t, ch))
+		assert.Equal(t, `{"msg":"second"}`, receive(t, ch))
+		assert.Equal(t, Connected, wr.(Connector).ConnState())
+	})
+
+	t.Run("Given full buffer should return error and drop the log", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		var reported error
+		wr := NewNetworkWriter(InfoLevel, NewConfig(
+			WithNetworkAddress("tcp", addr),
+			WithNetworkBuffer(20),
+			WithNetworkBackoff(time.Hour),
+			WithNetworkErrorHandler(func(err error) { reported = err }),
+		))
+		_, err = wr.Writer().Write([]byte(`{"msg":"first"}`))
+		require.NoError(t, err)
+		_, err = wr.Writer().Write([]byte(`{"msg":"second"}`))
+		assert.ErrorIs(t, err, errBufferFull)
+
+		// buffered log is reported as dropped once the deadline is passed
+		wr.Flush(10 * time.Millisecond)
+		assert.True(t, errors.Is(reported, context.Canceled))
+		assert.EqualError(t, reported, "failed to send 1 logs: context canceled")
+	})
+
+	t.Run("Given already flushed should send each log directly", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ch := lineServer(t, ln)
+
+		wr := NewNetworkWriter(InfoLevel, NewConfig(WithNetworkAddress("tcp", ln.Addr().String())))
+		wr.Flush(time.Second)
+		_, err = wr.Writer().Write([]byte(`{"msg":"late"}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"msg":"late"}`, receive(t, ch))
+	})
+}
+
+// partialConn net.Conn implementer that only accept up to limit bytes, then
+// return error.
+type partialConn struct {
+	net.Conn
+	limit int
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (p *partialConn) Write(b []byte) (int, error) {
+	if len(b) > p.limit {
+		return p.limit, errors.New("broken pipe")
+	}
+	p.limit -= len(b)
+	return len(b), nil
+}
+
+func (p *partialConn) SetWriteDeadline(t time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deadline = t
+	return nil
+}
+
+func (p *partialConn) Close() error { return nil }
+
+func TestNetworkWriterSend(t *testing.T) {
+	t.Run("Given partially failed write should not resend the logs that are completely written", func(t *testing.T) {
+		var reported error
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // stop drain right after the first attempt
+		n := &networkOutput{
+			cnf:  NetworkConfig{network: "tcp", backoff: time.Hour, onError: func(err error) { reported = err }},
+			conn: &partialConn{limit: 6},
+			ctx:  ctx,
+			buf:  [][]byte{[]byte("one\n"), []byte("two\n"), []byte("three\n")},
+			size: 14,
+		}
+		n.drain()
+
+		assert.EqualError(t, reported, "failed to write: broken pipe")
+		assert.Equal(t, [][]byte{[]byte("two\n"), []byte("three\n")}, n.buf)
+		assert.Equal(t, 10, n.size)
+	})
+
+	t.Run("Should not write longer than the deadline of given context", func(t *testing.T) {
+		conn := &partialConn{limit: 100}
+		n := &networkOutput{cnf: NetworkConfig{network: "tcp"}, conn: conn}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+
+		sent, err := n.send(ctx, [][]byte{[]byte("one\n")})
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		conn.mu.Lock()
+		defer conn.mu.Unlock()
+		assert.Equal(t, deadline, conn.deadline)
+	})
+
+	t.Run("Given canceled context should abort the blocked write", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer server.Close()
+		n := &networkOutput{cnf: NetworkConfig{network: "tcp"}, conn: client}
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		// nobody read from the pipe, so the write is blocked
+		start := time.Now()
+		_, err := n.send(ctx, [][]byte{[]byte("one\n")})
+		assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestConnStateOf(t *testing.T) {
+	t.Run("Given Writer wrapped by AsyncWriter should return the state of the wrapped Writer", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		_ = lineServer(t, ln)
+
+		wr := NewAsyncWriter(NewNetworkWriter(InfoLevel, NewConfig(WithNetworkAddress("tcp", ln.Addr().String()))))
+		defer wr.Flush(time.Second)
+		wr.Wait(time.Second)
+
+		state, ok := ConnStateOf(wr)
+		assert.True(t, ok)
+		assert.Equal(t, Connected, state)
+		assert.Equal(t, "connected", state.String())
+	})
+
+	t.Run("Given Writer that is not a Connector should return false", func(t *testing.T) {
+		state, ok := ConnStateOf(NewConsoleWriter(InfoLevel))
+		assert.False(t, ok)
+		assert.Equal(t, "disconnected", state.String())
+	})
+}
+
+func TestNetworkWriterLogger(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should send each log as JSON line", func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			ch := lineServer(t, ln)
+
+			wr := tc.fn(NewNetworkWriter(InfoLevel, NewConfig(WithNetworkAddress("tcp", ln.Addr().String()))))
+			wr.Init(time.Second)
+			wr.Dbg("debug log")
+			wr.Inf("info log", String("key", "val"))
+			wr.Flush(time.Second)
+
+			line := receive(t, ch)
+			assert.Contains(t, line, `"msg":"info log"`)
+			assert.Contains(t, line, `"key":"val"`)
+		})
+	}
+}
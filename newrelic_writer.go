@@ -25,12 +25,12 @@ func NewNewrelicWriter(lvl Level, cnf *Config) Writer {
 	if err != nil {
 		panic(errors.New("failed to init newrelic writer: " + err.Error()))
 	}
-	return &newrelicOutput{lvl: lvl, nr: nr}
+	return &newrelicOutput{lvl: NewAtomicLevel(lvl), nr: nr}
 }
 
 type newrelicOutput struct {
 	nr  *newrelic.Application
-	lvl Level
+	lvl *AtomicLevel
 }
 
 // Write implement io.Writer by passing the data to newrelic app.
@@ -39,8 +39,9 @@ func (n *newrelicOutput) Write(p []byte) (int, error) {
 	n.nr.RecordLog(newrelic.LogData{Message: string(msg)})
 	return len(p), nil
 }
-func (n *newrelicOutput) Writer() io.Writer       { return n }
-func (n *newrelicOutput) Output() Output          { return NEWRELIC }
-func (n *newrelicOutput) Level() Level            { return n.lvl }
-func (n *newrelicOutput) Wait(dur time.Duration)  { _ = n.nr.WaitForConnection(dur) }
-func (n *newrelicOutput) Flush(dur time.Duration) { n.nr.Shutdown(dur) }
+func (n *newrelicOutput) Writer() io.Writer         { return n }
+func (n *newrelicOutput) Output() Output            { return NEWRELIC }
+func (n *newrelicOutput) Level() Level              { return n.lvl.Level() }
+func (n *newrelicOutput) AtomicLevel() *AtomicLevel { return n.lvl }
+func (n *newrelicOutput) Wait(dur time.Duration)    { _ = n.nr.WaitForConnection(dur) }
+func (n *newrelicOutput) Flush(dur time.Duration)   { n.nr.Shutdown(dur) }
@@ -2,18 +2,24 @@ package apilog
 
 import "time"
 
-// NewNop returns a no-op Logger. Do nothing and never writes out any logs.
+// NewNop returns a no-op Logger. Do nothing and never writes out any logs,
+// except Pnc and Ftl that still panics and exits respectively.
 func NewNop() Logger {
 	return &nopLogger{}
 }
 
 type nopLogger struct{}
 
-func (n nopLogger) Init(_ time.Duration)            {}
-func (n nopLogger) Flush(_ time.Duration)           {}
-func (n nopLogger) With(_ ...Log) Logger            { return n }
-func (n nopLogger) Group(_ string, _ ...Log) Logger { return n }
-func (n nopLogger) Dbg(_ string, _ ...Log)          {}
-func (n nopLogger) Inf(_ string, _ ...Log)          {}
-func (n nopLogger) Wrn(_ string, _ ...Log)          {}
-func (n nopLogger) Err(_ string, _ ...Log)          {}
+func (n nopLogger) Init(_ time.Duration)              {}
+func (n nopLogger) Flush(_ time.Duration)             {}
+func (n nopLogger) Enabled(_ Level) bool              { return false }
+func (n nopLogger) WithOptions(_ ...LoggerOpt) Logger { return n }
+func (n nopLogger) With(_ ...Log) Logger              { return n }
+func (n nopLogger) Group(_ string, _ ...Log) Logger   { return n }
+func (n nopLogger) Trc(_ string, _ ...Log)            {}
+func (n nopLogger) Dbg(_ string, _ ...Log)            {}
+func (n nopLogger) Inf(_ string, _ ...Log)            {}
+func (n nopLogger) Wrn(_ string, _ ...Log)            {}
+func (n nopLogger) Err(_ string, _ ...Log)            {}
+func (n nopLogger) Pnc(msg string, _ ...Log)          { panic(msg) }
+func (n nopLogger) Ftl(_ string, _ ...Log)            { exit(1) }
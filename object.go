@@ -0,0 +1,37 @@
+package apilog
+
+import "time"
+
+// ObjectMarshaler allows user-defined types to efficiently add themselves to
+// the log without reflection, and also control exactly which fields should be
+// logged.
+type ObjectMarshaler interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// ObjectEncoder strongly-typed, backend-agnostic encoder used by
+// ObjectMarshaler to add its fields. Each method follow how the equivalent
+// Log constructor is encoded.
+type ObjectEncoder interface {
+	// AddString add the key and value the same way as String.
+	AddString(k, v string)
+	// AddNum add the key and value the same way as Num.
+	AddNum(k string, v int)
+	// AddInt64 add the key and value the same way as Int64.
+	AddInt64(k string, v int64)
+	// AddUint64 add the key and value the same way as Uint64.
+	AddUint64(k string, v uint64)
+	// AddFloat add the key and value the same way as Float.
+	AddFloat(k string, v float64)
+	// AddBool add the key and value the same way as Bool.
+	AddBool(k string, v bool)
+	// AddDuration add the key and value the same way as Duration.
+	AddDuration(k string, v time.Duration)
+	// AddTime add the key and value the same way as Time.
+	AddTime(k string, v time.Time)
+	// AddObject add the key and nested object the same way as Object.
+	AddObject(k string, v ObjectMarshaler) error
+	// AddAny add the key and value the same way as Any, which may use
+	// reflection.
+	AddAny(k string, v any) error
+}
@@ -0,0 +1,146 @@
+package apilog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string
+}
+
+func (a testAddress) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("city", a.City)
+	return nil
+}
+
+type testUser struct {
+	ID       int
+	Age      int64
+	Quota    uint64
+	Score    float64
+	Active   bool
+	Session  time.Duration
+	Joined   time.Time
+	Address  testAddress
+	Roles    []string
+	Password string
+	fail     bool
+}
+
+func (u testUser) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddNum("id", u.ID)
+	enc.AddInt64("age", u.Age)
+	enc.AddUint64("quota", u.Quota)
+	enc.AddFloat("score", u.Score)
+	enc.AddBool("active", u.Active)
+	enc.AddDuration("session", u.Session)
+	enc.AddTime("joined", u.Joined)
+	if err := enc.AddObject("address", u.Address); err != nil {
+		return err
+	}
+	if err := enc.AddAny("roles", u.Roles); err != nil {
+		return err
+	}
+	// password is intentionally not logged
+	if u.fail {
+		return errors.New("oops")
+	}
+	return nil
+}
+
+// countedObject ObjectMarshaler that count how many times it's marshaled.
+type countedObject struct {
+	n *int
+}
+
+func (c countedObject) MarshalLogObject(enc ObjectEncoder) error {
+	*c.n++
+	enc.AddString("name", "counted")
+	return nil
+}
+
+func TestObject(t *testing.T) {
+	usr := testUser{
+		ID:       1,
+		Age:      30,
+		Quota:    100,
+		Score:    9.5,
+		Active:   true,
+		Session:  time.Minute,
+		Joined:   time.Date(2024, 8, 28, 7, 59, 13, 0, time.UTC),
+		Address:  testAddress{City: "Jakarta"},
+		Roles:    []string{"admin"},
+		Password: "secret",
+	}
+
+	t.Run("Should set the type to ObjectType", func(t *testing.T) {
+		obj := Object("user", usr)
+		assert.Equal(t, ObjectType, obj.typ)
+		assert.Equal(t, "user", obj.key)
+		assert.Equal(t, usr, obj.any)
+	})
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should only encode the marshaled fields", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer)
+			wr.Init(time.Microsecond)
+			wr.Inf("object", Object("user", usr))
+
+			require.Equal(t, 1, obs.Len())
+			expect := map[string]any{
+				"id":      float64(1),
+				"age":     float64(30),
+				"quota":   float64(100),
+				"score":   9.5,
+				"active":  true,
+				"session": "1m0s",
+				"joined":  "2024-08-28T07:59:13Z",
+				"address": map[string]any{"city": "Jakarta"},
+				"roles":   []any{"admin"},
+			}
+			assert.Equal(t, expect, obs.All()[0].Get("user"))
+			assert.Nil(t, obs.All()[0].Get("userError"))
+		})
+
+		t.Run(tc.name+" should add the error as another field", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer)
+			wr.Init(time.Microsecond)
+			failed := usr
+			failed.fail = true
+			wr.With(Object("user", failed)).Inf("object")
+
+			require.Equal(t, 1, obs.Len())
+			assert.Equal(t, "oops", obs.All()[0].Get("userError"))
+			assert.NotNil(t, obs.All()[0].Get("user"))
+		})
+
+		t.Run(tc.name+" should only marshal the object when the log is written", func(t *testing.T) {
+			writer, obs := NewObserverWriter(InfoLevel, FILE)
+			// only the first one pass the sampling
+			wr := tc.fn(NewSampledWriter(writer, time.Minute, 1, 0))
+			wr.Init(time.Microsecond)
+			var n int
+			for i := 0; i < 3; i++ {
+				wr.Inf("object", Object("counted", countedObject{&n}))
+			}
+
+			assert.Equal(t, 1, n)
+			require.Equal(t, 1, obs.Len())
+			assert.Equal(t, map[string]any{"name": "counted"}, obs.All()[0].Get("counted"))
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// otlpLogsPath path of OTLP/HTTP logs endpoint.
+const otlpLogsPath = "/v1/logs"
+
+// otlpScope name of the instrumentation scope of each log record.
+const otlpScope = "github.com/mdanialr/apilog"
+
+// NewOTLPWriter return Writer implementer that export logs in batches as
+// OpenTelemetry log records to OTLP/HTTP endpoint by given Config and set
+// given lvl as the log Level. Every field is converted to attribute, nested
+// fields e.g. from Group are flattened using dot separated key. Batching,
+// retry, headers and error handling are configured using the same options as
+// NewHTTPWriter e.g. WithHTTPBatchSize, except the URL.
+func NewOTLPWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	o := &otlpEncoder{cnf: cnf.otlp}
+	// set default value
+	if o.cnf.service == "" {
+		o.cnf.service = filepath.Base(os.Args[0])
+	}
+	o.resource = o.resourceAttrs()
+
+	hc := cnf.http
+	hc.url = endpointURL(cnf.otlp.endpoint, "http://localhost:4318", otlpLogsPath)
+	hc.contentType = "application/json"
+	return newHTTPBatcher(OTLP, lvl, hc, o.encode)
+}
+
+// toOTLPSeverity map given Level to OpenTelemetry severity number. Unknown
+// Level is treated as unspecified.
+func toOTLPSeverity(lvl Level) int {
+	switch lvl {
+	case TraceLevel:
+		return 1 // TRACE
+	case DebugLevel:
+		return 5 // DEBUG
+	case InfoLevel:
+		return 9 // INFO
+	case WarnLevel:
+		return 13 // WARN
+	case ErrorLevel:
+		return 17 // ERROR
+	case PanicLevel:
+		return 22 // FATAL2
+	case FatalLevel:
+		return 23 // FATAL3
+	}
+	return 0
+}
+
+type (
+	otlpRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScopeName   `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScopeName struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber,omitempty"`
+		SeverityText         string         `json:"severityText,omitempty"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string        `json:"stringValue,omitempty"`
+		BoolValue   *bool          `json:"boolValue,omitempty"`
+		IntValue    *string        `json:"intValue,omitempty"`
+		DoubleValue *float64       `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArray     `json:"arrayValue,omitempty"`
+		KvlistValue *otlpKeyValues `json:"kvlistValue,omitempty"`
+	}
+	otlpArray struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+	otlpKeyValues struct {
+		Values []otlpKeyValue `json:"values"`
+	}
+)
+
+// otlpEncoder encode the batch as OTLP/HTTP logs export request in JSON
+// format.
+type otlpEncoder struct {
+	cnf      OTLPConfig
+	resource []otlpKeyValue
+}
+
+// resourceAttrs return the resource attributes sorted by the key.
+func (o *otlpEncoder) resourceAttrs() []otlpKeyValue {
+	attrs := map[string]string{"service.name": o.cnf.service}
+	for k, v := range o.cnf.resource {
+		attrs[k] = v
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpString(v)})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+// encode given entries as OTLP/HTTP logs export request.
+func (o *otlpEncoder) encode(entries [][]byte) ([]byte, error) {
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpLogRecord, 0, len(entries))
+	for _, e := range entries {
+		records = append(records, o.record(e, observed))
+	}
+
+	return json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: o.resource},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScopeName{Name: otlpScope}, LogRecords: records}},
+	}}})
+}
+
+// record convert given JSON encoded log to OTLP log record. The log is used
+// as the body as is if it's not a JSON object.
+func (o *otlpEncoder) record(e []byte, observed string) otlpLogRecord {
+	rec := otlpLogRecord{ObservedTimeUnixNano: observed}
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(e))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		rec.TimeUnixNano = observed
+		rec.Body = otlpString(string(e))
+		return rec
+	}
+
+	rec.TimeUnixNano = strconv.FormatInt(timeOf(fields).UnixNano(), 10)
+	if s, ok := fields["level"].(string); ok {
+		rec.SeverityText = s
+		rec.SeverityNumber = toOTLPSeverity(ParseLevel(s))
+	}
+	if s, ok := fields["msg"].(string); ok {
+		rec.Body = otlpString(s)
+	}
+	delete(fields, "level")
+	delete(fields, "time")
+	delete(fields, "msg")
+	rec.Attributes = otlpAttrs("", fields, nil)
+	return rec
+}
+
+// otlpAttrs append given fields as attributes to given kvs sorted by the key.
+// Nested object is flattened using dot separated key prefixed by given
+// prefix.
+func otlpAttrs(prefix string, fields map[string]any, kvs []otlpKeyValue) []otlpKeyValue {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if m, ok := fields[k].(map[string]any); ok && len(m) > 0 {
+			kvs = otlpAttrs(prefix+k+".", m, kvs)
+			continue
+		}
+		kvs = append(kvs, otlpKeyValue{Key: prefix + k, Value: otlpValue(fields[k])})
+	}
+	return kvs
+}
+
+// otlpValue convert given JSON decoded value to OTLP AnyValue.
+func otlpValue(v any) otlpAnyValue {
+	switch val := v.(type) {
+	case string:
+		return otlpString(val)
+	case bool:
+		return otlpAnyValue{BoolValue: &val}
+	case json.Number:
+		if _, err := strconv.ParseInt(val.String(), 10, 64); err == nil {
+			s := val.String()
+			return otlpAnyValue{IntValue: &s}
+		}
+		if f, err := val.Float64(); err == nil {
+			return otlpAnyValue{DoubleValue: &f}
+		}
+		return otlpString(val.String())
+	case []any:
+		arr := &otlpArray{Values: make([]otlpAnyValue, len(val))}
+		for i, e := range val {
+			arr.Values[i] = otlpValue(e)
+		}
+		return otlpAnyValue{ArrayValue: arr}
+	case map[string]any:
+		kvs := &otlpKeyValues{Values: make([]otlpKeyValue, 0, len(val))}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kvs.Values = append(kvs.Values, otlpKeyValue{Key: k, Value: otlpValue(val[k])})
+		}
+		return otlpAnyValue{KvlistValue: kvs}
+	}
+	// null
+	return otlpAnyValue{}
+}
+
+func otlpString(s string) otlpAnyValue { return otlpAnyValue{StringValue: &s} }
@@ -0,0 +1,152 @@
+package apilog
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToOTLPSeverity(t *testing.T) {
+	testCases := []struct {
+		lvl    Level
+		expect int
+	}{
+		{lvl: TraceLevel, expect: 1},
+		{lvl: DebugLevel, expect: 5},
+		{lvl: InfoLevel, expect: 9},
+		{lvl: WarnLevel, expect: 13},
+		{lvl: ErrorLevel, expect: 17},
+		{lvl: PanicLevel, expect: 22},
+		{lvl: FatalLevel, expect: 23},
+		{lvl: -1, expect: 0},
+	}
+	for _, tc := range testCases {
+		t.Run("Given "+tc.lvl.String()+" level should map to severity number "+strconv.Itoa(tc.expect), func(t *testing.T) {
+			assert.Equal(t, tc.expect, toOTLPSeverity(tc.lvl))
+		})
+	}
+}
+
+func TestOTLPEncoder(t *testing.T) {
+	o := &otlpEncoder{cnf: OTLPConfig{service: "api", resource: map[string]string{"env": "prod"}}}
+	o.resource = o.resourceAttrs()
+
+	t.Run("Should convert each field to attribute with the matching type", func(t *testing.T) {
+		b, err := o.encode([][]byte{[]byte(`{"level":"WARN","time":"2026-10-17T07:59:13.5Z","msg":"hello",` +
+			`"id":9223372036854775807,"ratio":0.5,"ok":true,"none":null,"tags":["a",1],` +
+			`"context":{"app":"api","req":{"id":"x"}},"empty":{}}`)})
+		require.NoError(t, err)
+
+		var req map[string]any
+		require.NoError(t, json.Unmarshal(b, &req))
+		rl := req["resourceLogs"].([]any)[0].(map[string]any)
+		assert.Equal(t, []any{
+			map[string]any{"key": "env", "value": map[string]any{"stringValue": "prod"}},
+			map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "api"}},
+		}, rl["resource"].(map[string]any)["attributes"])
+
+		sl := rl["scopeLogs"].([]any)[0].(map[string]any)
+		assert.Equal(t, otlpScope, sl["scope"].(map[string]any)["name"])
+		rec := sl["logRecords"].([]any)[0].(map[string]any)
+		assert.Equal(t, "1792223953500000000", rec["timeUnixNano"])
+		assert.NotEmpty(t, rec["observedTimeUnixNano"])
+		assert.Equal(t, float64(13), rec["severityNumber"])
+		assert.Equal(t, "WARN", rec["severityText"])
+		assert.Equal(t, map[string]any{"stringValue": "hello"}, rec["body"])
+
+		attrs := rec["attributes"].([]any)
+		expect := []string{
+			`{"key":"context.app","value":{"stringValue":"api"}}`,
+			`{"key":"context.req.id","value":{"stringValue":"x"}}`,
+			`{"key":"empty","value":{"kvlistValue":{"values":[]}}}`,
+			`{"key":"id","value":{"intValue":"9223372036854775807"}}`,
+			`{"key":"none","value":{}}`,
+			`{"key":"ok","value":{"boolValue":true}}`,
+			`{"key":"ratio","value":{"doubleValue":0.5}}`,
+			`{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"a"},{"intValue":"1"}]}}}`,
+		}
+		require.Len(t, attrs, len(expect))
+		for i, e := range expect {
+			b, _ := json.Marshal(attrs[i])
+			assert.JSONEq(t, e, string(b))
+		}
+	})
+
+	t.Run("Given non JSON object log should use it as the body", func(t *testing.T) {
+		rec := o.record([]byte("plain text"), "1")
+		assert.Equal(t, "plain text", *rec.Body.StringValue)
+		assert.Equal(t, "1", rec.TimeUnixNano)
+		assert.Empty(t, rec.Attributes)
+	})
+}
+
+func TestNewOTLPWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewOTLPWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.IsType(t, &httpBatcher{}, wr.Writer())
+		assert.Equal(t, OTLP, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "http://localhost:4318/v1/logs", h.cnf.url)
+		assert.Equal(t, "application/json", h.cnf.contentType)
+	})
+}
+
+func TestOTLPWriter(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should export each log as OTLP log record", func(t *testing.T) {
+			srv, ch := newHTTPServer(t, nil)
+			ot := NewOTLPWriter(InfoLevel, NewConfig(
+				WithOTLPEndpoint(srv.URL),
+				WithOTLPServiceName("api"),
+				WithHTTPHeader("Authorization", "Bearer token"),
+			))
+			wr := tc.fn(ot)
+			wr.Init(time.Microsecond)
+			wr.Group("req", String("id", "x")).Err("oops", Num("code", 500))
+			wr.Flush(time.Second)
+
+			req := receiveRequest(t, ch)
+			assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+			var body struct {
+				ResourceLogs []struct {
+					ScopeLogs []struct {
+						LogRecords []struct {
+							SeverityNumber int            `json:"severityNumber"`
+							SeverityText   string         `json:"severityText"`
+							Body           map[string]any `json:"body"`
+							Attributes     []otlpKeyValue `json:"attributes"`
+						} `json:"logRecords"`
+					} `json:"scopeLogs"`
+				} `json:"resourceLogs"`
+			}
+			require.NoError(t, json.Unmarshal(req.body, &body))
+			rec := body.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+			assert.Equal(t, 17, rec.SeverityNumber)
+			assert.Equal(t, "ERROR", rec.SeverityText)
+			assert.Equal(t, "oops", rec.Body["stringValue"])
+
+			attrs := make(map[string]otlpAnyValue)
+			for _, kv := range rec.Attributes {
+				attrs[kv.Key] = kv.Value
+			}
+			require.Contains(t, attrs, "req.id")
+			assert.Equal(t, "x", *attrs["req.id"].StringValue)
+			require.Contains(t, attrs, "code")
+			assert.Equal(t, "500", *attrs["code"].IntValue)
+		})
+	}
+}
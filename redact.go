@@ -0,0 +1,339 @@
+package apilog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Masker transform sensitive value to its masked form.
+type Masker func(s string) string
+
+// MaskFull replace the whole value with '***'.
+func MaskFull(_ string) string { return "***" }
+
+// MaskHash replace the value with the first 16 characters of its sha256
+// checksum, so the same value can still be correlated across logs.
+func MaskHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// MaskPartial return Masker that keep given number of prefix and suffix
+// characters and replace the rest with '***'. Fully masked if the value is
+// too short.
+func MaskPartial(prefix, suffix int) Masker {
+	return func(s string) string {
+		r := []rune(s)
+		if len(r) <= prefix+suffix {
+			return "***"
+		}
+		return string(r[:prefix]) + "***" + string(r[len(r)-suffix:])
+	}
+}
+
+// WithRedaction mask sensitive Log(s) based on the redact config in given cnf
+// before written by any Writer. Applied to nested values in Group, Object and
+// maps or slices passed to Any as well. Struct passed to Any is not
+// inspected, use Object instead.
+func WithRedaction(cnf *Config) LoggerOpt {
+	return func(o *loggerOpts) {
+		if cnf == nil {
+			cnf = &Config{}
+		}
+		o.redactor = newRedactor(&cnf.redact)
+	}
+}
+
+// maxRedactDepth maximum depth of nested maps or slices to be inspected.
+const maxRedactDepth = 32
+
+// redactor mask sensitive Log(s) based on the RedactConfig.
+type redactor struct {
+	cnf  *RedactConfig
+	keys map[string]struct{}
+	mask Masker
+}
+
+func newRedactor(cnf *RedactConfig) *redactor {
+	r := &redactor{cnf: cnf, keys: make(map[string]struct{}), mask: cnf.masker}
+	for _, k := range cnf.keys {
+		r.keys[strings.ToLower(k)] = struct{}{}
+	}
+	if r.mask == nil {
+		r.mask = MaskFull
+	}
+	return r
+}
+
+// redact return copy of given Log(s) after masking the sensitive ones. Return
+// as is if r is nil.
+func (r *redactor) redact(pr []Log) []Log {
+	if r == nil || len(pr) == 0 {
+		return pr
+	}
+	out := make([]Log, len(pr))
+	for i, p := range pr {
+		out[i] = r.redactLog(p)
+	}
+	return out
+}
+
+func (r *redactor) redactLog(p Log) Log {
+	switch p.typ {
+	case LazyType:
+		// keep it lazy
+		return Lazy(p.key, func() Log { return r.redactLog(p.resolve()) })
+	case SecretType:
+		return String(p.key, r.mask(p.str))
+	}
+	if r.sensitiveKey(p.key) {
+		switch p.typ {
+		case AnyType:
+			return String(p.key, r.maskOf(p.any))
+		case StringsType, IntsType, ObjectType:
+			return String(p.key, MaskFull(""))
+		}
+		return String(p.key, r.mask(p.valueString()))
+	}
+
+	switch p.typ {
+	case StringType:
+		p.str = r.maskValue(p.str)
+	case BytesType:
+		p.any = []byte(r.maskValue(string(p.any.([]byte))))
+	case StringsType:
+		strs := make([]string, len(p.any.([]string)))
+		for i, s := range p.any.([]string) {
+			strs[i] = r.maskValue(s)
+		}
+		p.any = strs
+	case StringerType:
+		p.any = safeStringer{v: redactStringer{v: p.any.(fmt.Stringer), r: r}}
+	case ErrorType:
+		if p.err != nil {
+			if msg := r.maskValue(p.err.Error()); msg != p.err.Error() {
+				p.err = errors.New(msg)
+			}
+		}
+	case AnyType:
+		p.any = r.redactAny(p.any, 0)
+	case ObjectType:
+		p.any = redactObject{v: p.any.(ObjectMarshaler), r: r}
+	}
+	return p
+}
+
+// sensitiveKey return true if given k match any of the configured keys.
+func (r *redactor) sensitiveKey(k string) bool {
+	if _, ok := r.keys[strings.ToLower(k)]; ok {
+		return true
+	}
+	for _, re := range r.cnf.keyRe {
+		if re.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskOf mask given v with the configured Masker if it is a string or a
+// number. Otherwise fully masked, since masking only part of its formatted
+// dump may still leak the rest.
+func (r *redactor) maskOf(v any) string {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return r.mask(fmt.Sprint(v))
+	}
+	return MaskFull("")
+}
+
+// maskValue mask any part of given s that match the configured value patterns.
+func (r *redactor) maskValue(s string) string {
+	for _, re := range r.cnf.valueRe {
+		s = re.ReplaceAllStringFunc(s, r.mask)
+	}
+	return s
+}
+
+// redactAny walk through maps with string keys and slices to mask the
+// sensitive values. Other types are returned as is.
+func (r *redactor) redactAny(v any, depth int) any {
+	if v == nil || depth > maxRedactDepth {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, val := iter.Key().String(), iter.Value().Interface()
+			if r.sensitiveKey(k) {
+				m[k] = r.maskOf(val)
+				continue
+			}
+			m[k] = r.redactAny(val, depth+1)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		// keep byte slice as is
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = r.redactAny(rv.Index(i).Interface(), depth+1)
+		}
+		return s
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return v
+		}
+		if k := rv.Elem().Kind(); k == reflect.Map || k == reflect.Slice || k == reflect.Array {
+			return r.redactAny(rv.Elem().Interface(), depth+1)
+		}
+	case reflect.String:
+		return r.maskValue(rv.String())
+	}
+	return v
+}
+
+// valueString return the string representation of the Log value.
+func (l Log) valueString() string {
+	switch l.typ {
+	case StringType, SecretType:
+		return l.str
+	case NumType:
+		return strconv.Itoa(l.num)
+	case Int64Type:
+		return strconv.FormatInt(l.i64, 10)
+	case Uint64Type:
+		return strconv.FormatUint(l.u64, 10)
+	case FloatType:
+		return strconv.FormatFloat(l.flt, 'f', -1, 64)
+	case BoolType:
+		return strconv.FormatBool(l.b)
+	case DurationType:
+		return time.Duration(l.i64).String()
+	case TimeType:
+		return l.any.(time.Time).Format(time.RFC3339Nano)
+	case BytesType:
+		return string(l.any.([]byte))
+	case ErrorType:
+		if l.err != nil {
+			return l.err.Error()
+		}
+		return "<nil>"
+	case StringerType:
+		return l.any.(fmt.Stringer).String()
+	}
+	return fmt.Sprint(l.any)
+}
+
+// redactStringer mask the value patterns of the wrapped fmt.Stringer.
+type redactStringer struct {
+	v fmt.Stringer
+	r *redactor
+}
+
+func (s redactStringer) String() string { return s.r.maskValue(stringerOf(s.v)) }
+
+// redactObject mask the sensitive fields of the wrapped ObjectMarshaler.
+type redactObject struct {
+	v ObjectMarshaler
+	r *redactor
+}
+
+func (o redactObject) MarshalLogObject(enc ObjectEncoder) error {
+	return o.v.MarshalLogObject(redactEncoder{enc: enc, r: o.r})
+}
+
+// redactEncoder ObjectEncoder that mask the sensitive fields before passing
+// them to the wrapped ObjectEncoder.
+type redactEncoder struct {
+	enc ObjectEncoder
+	r   *redactor
+}
+
+// masked add masked v to the wrapped encoder if k is sensitive.
+func (e redactEncoder) masked(k string, v any) bool {
+	if !e.r.sensitiveKey(k) {
+		return false
+	}
+	e.enc.AddString(k, e.r.maskOf(v))
+	return true
+}
+
+func (e redactEncoder) AddString(k, v string) {
+	if !e.masked(k, v) {
+		e.enc.AddString(k, e.r.maskValue(v))
+	}
+}
+
+func (e redactEncoder) AddNum(k string, v int) {
+	if !e.masked(k, v) {
+		e.enc.AddNum(k, v)
+	}
+}
+
+func (e redactEncoder) AddInt64(k string, v int64) {
+	if !e.masked(k, v) {
+		e.enc.AddInt64(k, v)
+	}
+}
+
+func (e redactEncoder) AddUint64(k string, v uint64) {
+	if !e.masked(k, v) {
+		e.enc.AddUint64(k, v)
+	}
+}
+
+func (e redactEncoder) AddFloat(k string, v float64) {
+	if !e.masked(k, v) {
+		e.enc.AddFloat(k, v)
+	}
+}
+
+func (e redactEncoder) AddBool(k string, v bool) {
+	if !e.masked(k, v) {
+		e.enc.AddBool(k, v)
+	}
+}
+
+func (e redactEncoder) AddDuration(k string, v time.Duration) {
+	if !e.masked(k, v) {
+		e.enc.AddDuration(k, v)
+	}
+}
+
+func (e redactEncoder) AddTime(k string, v time.Time) {
+	if !e.masked(k, v.Format(time.RFC3339Nano)) {
+		e.enc.AddTime(k, v)
+	}
+}
+
+func (e redactEncoder) AddObject(k string, v ObjectMarshaler) error {
+	if e.r.sensitiveKey(k) {
+		e.enc.AddString(k, MaskFull(""))
+		return nil
+	}
+	return e.enc.AddObject(k, redactObject{v: v, r: e.r})
+}
+
+func (e redactEncoder) AddAny(k string, v any) error {
+	if e.masked(k, v) {
+		return nil
+	}
+	return e.enc.AddAny(k, e.r.redactAny(v, 0))
+}
@@ -0,0 +1,214 @@
+package apilog
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMasker(t *testing.T) {
+	assert.Equal(t, "***", MaskFull("secret"))
+	assert.Equal(t, "sha256:2bb80d537b1da3e3", MaskHash("secret"))
+	assert.Equal(t, "jo***.com", MaskPartial(2, 4)("john@mail.com"))
+	assert.Equal(t, "***", MaskPartial(2, 4)("short"))
+}
+
+// testCredential ObjectMarshaler that hold sensitive fields.
+type testCredential struct {
+	User  string
+	Token string
+	Inner *testCredential
+}
+
+func (c testCredential) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("api_token", c.Token)
+	enc.AddNum("password", 1234)
+	if c.Inner != nil {
+		return enc.AddObject("inner", c.Inner)
+	}
+	return nil
+}
+
+func TestRedactor(t *testing.T) {
+	cnf := NewConfig(
+		WithRedactKeys("Password", "email"),
+		WithRedactKeyPattern(regexp.MustCompile(`(?i)token$`)),
+		WithRedactValuePattern(regexp.MustCompile(`[a-z]+@mail\.com`)),
+	)
+	r := newRedactor(&cnf.redact)
+
+	t.Run("Nil redactor should return as is", func(t *testing.T) {
+		var nr *redactor
+		pr := []Log{String("password", "secret")}
+		assert.Equal(t, pr, nr.redact(pr))
+	})
+
+	testCases := []struct {
+		name   string
+		sample Log
+		expect Log
+	}{
+		{
+			name:   "Sensitive key should be fully masked",
+			sample: String("password", "secret"),
+			expect: String("password", "***"),
+		},
+		{
+			name:   "Sensitive key is case-insensitive and not limited to string",
+			sample: Num("PASSWORD", 1234),
+			expect: String("PASSWORD", "***"),
+		},
+		{
+			name:   "Key pattern",
+			sample: String("X-Api-Token", "abc"),
+			expect: String("X-Api-Token", "***"),
+		},
+		{
+			name:   "Value pattern should only mask the matched part",
+			sample: String("note", "contact me at john@mail.com please"),
+			expect: String("note", "contact me at *** please"),
+		},
+		{
+			name:   "Value pattern in strings",
+			sample: Strings("to", []string{"john@mail.com", "team"}),
+			expect: Strings("to", []string{"***", "team"}),
+		},
+		{
+			name:   "Value pattern in bytes",
+			sample: Bytes("body", []byte("john@mail.com")),
+			expect: Bytes("body", []byte("***")),
+		},
+		{
+			name:   "Value pattern in error",
+			sample: Error(errors.New("user john@mail.com not found")),
+			expect: Error(errors.New("user *** not found")),
+		},
+		{
+			name:   "Secret should always be masked",
+			sample: Secret("anything", "secret"),
+			expect: String("anything", "***"),
+		},
+		{
+			name:   "Non-sensitive should be kept as is",
+			sample: Num("id", 1),
+			expect: Num("id", 1),
+		},
+		{
+			name: "Nested map and slice in Any",
+			sample: Any("req", map[string]any{
+				"email": "john@mail.com",
+				"items": []map[string]string{{"password": "secret", "name": "john@mail.com"}},
+				"id":    1,
+			}),
+			expect: Any("req", map[string]any{
+				"email": "***",
+				"items": []any{map[string]any{"password": "***", "name": "***"}},
+				"id":    1,
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, []Log{tc.expect}, r.redact([]Log{tc.sample}))
+		})
+	}
+
+	t.Run("Should not modify the original value", func(t *testing.T) {
+		m := map[string]string{"password": "secret"}
+		_ = r.redact([]Log{Any("m", m)})
+		assert.Equal(t, "secret", m["password"])
+	})
+
+	t.Run("Lazy should stay lazy", func(t *testing.T) {
+		var called bool
+		out := r.redact([]Log{Lazy("password", func() Log {
+			called = true
+			return String("", "secret")
+		})})
+		assert.False(t, called)
+		assert.Equal(t, String("password", "***"), out[0].resolve())
+		assert.True(t, called)
+	})
+
+	t.Run("Stringer should be masked by value pattern", func(t *testing.T) {
+		u, _ := url.Parse("https://john@mail.com/path")
+		out := r.redact([]Log{Stringer("url", u)})
+		assert.Equal(t, "https://***/path", out[0].valueString())
+	})
+}
+
+func TestWithRedaction(t *testing.T) {
+	cnf := NewConfig(
+		WithRedactKeys("password"),
+		WithRedactKeyPattern(regexp.MustCompile(`token$`)),
+		WithRedactValuePattern(regexp.MustCompile(`[a-z]+@mail\.com`)),
+		WithRedactMasker(MaskPartial(1, 1)),
+	)
+	cred := testCredential{User: "john@mail.com", Token: "abcdef", Inner: &testCredential{User: "bot", Token: "xyz"}}
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should mask every sensitive Log", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer).WithOptions(WithRedaction(cnf))
+			wr.Init(time.Microsecond)
+
+			wr = wr.With(String("password", "secret"))
+			wr = wr.Group("req", String("user", "john@mail.com"), String("refresh_token", "abcdef"))
+			wr.Inf("redacted", Object("cred", cred), Secret("pin", "123456"))
+
+			require.Equal(t, 1, obs.Len())
+			lg := obs.All()[0]
+			assert.Equal(t, "s***t", lg.Get("password"))
+			assert.Equal(t, "1***6", lg.Get("pin"))
+			assert.Equal(t, map[string]any{"user": "j***m", "refresh_token": "a***f"}, lg.Get("req"))
+			assert.Equal(t, map[string]any{
+				"user":      "j***m",
+				"api_token": "a***f",
+				"password":  "1***4",
+				"inner":     map[string]any{"user": "bot", "api_token": "x***z", "password": "1***4"},
+			}, lg.Get("cred"))
+		})
+
+		t.Run(tc.name+" should fully mask sensitive key holding non-scalar value", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer).WithOptions(WithRedaction(cnf))
+			wr.Init(time.Microsecond)
+			wr.Inf("redacted",
+				Object("password", cred),
+				Any("refresh_token", map[string]any{"value": "abcdef"}),
+				Any("req", map[string]any{"password": []string{"secret"}, "api_token": "abcdef"}),
+			)
+
+			require.Equal(t, 1, obs.Len())
+			lg := obs.All()[0]
+			assert.Equal(t, "***", lg.Get("password"))
+			assert.Equal(t, "***", lg.Get("refresh_token"))
+			assert.Equal(t, map[string]any{"password": "***", "api_token": "a***f"}, lg.Get("req"))
+		})
+
+		t.Run(tc.name+" should always mask Secret even without redaction", func(t *testing.T) {
+			writer, obs := NewObserverWriter(DebugLevel, FILE)
+			wr := tc.fn(writer).WithOptions(WithRedaction(nil))
+			wr.Init(time.Microsecond)
+			wr.Inf("secret", Secret("pin", "123456"), String("password", "secret"))
+
+			require.Equal(t, 1, obs.Len())
+			assert.Equal(t, "***", obs.All()[0].Get("pin"))
+			assert.Equal(t, "secret", obs.All()[0].Get("password"))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package apilog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ReopenOnSIGHUP reopen every given Writer that implement Reopener, including
+// the wrapped ones, whenever the process receive SIGHUP. This is what
+// logrotate expects when configured without copytruncate. Writer that does
+// not implement Reopener is ignored. Call the returned stop to stop listening
+// to the signal.
+func ReopenOnSIGHUP(wr ...Writer) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	stopReopen := reopenOn(ch, wr)
+	return func() {
+		signal.Stop(ch)
+		stopReopen()
+	}
+}
+
+// reopenOn reopen every Reopener of given wr whenever receiving from ch until
+// the returned stop is called. Nothing is reopened once stop returned.
+func reopenOn(ch <-chan os.Signal, wr []Writer) (stop func()) {
+	var rs []Reopener
+	for _, w := range wr {
+		if r := reopenerOf(w); r != nil {
+			rs = append(rs, r)
+		}
+	}
+
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-ch:
+				for _, r := range rs {
+					// the next log retry to open the file anyway
+					_ = r.Reopen()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
@@ -0,0 +1,147 @@
+package apilog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countLines return the number of lines in every given file.
+func countLines(t *testing.T, names ...string) int {
+	var n int
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		require.NoError(t, err)
+		n += bytes.Count(b, []byte("\n"))
+	}
+	return n
+}
+
+func TestFileWriterReopen(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []ConfigOpt
+	}{
+		{name: "Lumberjack"},
+		{name: "Rotator", opts: []ConfigOpt{WithFileMode(0640)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should write to the new file after reopened", func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "app.log")
+			fl := NewFileWriter(InfoLevel, NewConfig(append(tc.opts, WithFilePath(name))...))
+			wr := NewZapLogger(fl)
+			wr.Init(time.Microsecond)
+
+			wr.Inf("before")
+			// simulate logrotate
+			require.NoError(t, os.Rename(name, name+".1"))
+			wr.Inf("moved")
+			require.NoError(t, fl.(Reopener).Reopen())
+			wr.Inf("after")
+			wr.Flush(time.Second)
+
+			b, err := os.ReadFile(name + ".1")
+			require.NoError(t, err)
+			assert.Contains(t, string(b), "before")
+			assert.Contains(t, string(b), "moved")
+			b, err = os.ReadFile(name)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), "after")
+			assert.NotContains(t, string(b), "before")
+		})
+
+		t.Run(tc.name+" should not drop any log while reopened concurrently", func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "app.log")
+			fl := NewFileWriter(InfoLevel, NewConfig(append(tc.opts, WithFilePath(name))...))
+			wr := NewZapLogger(fl)
+			wr.Init(time.Microsecond)
+
+			const total = 1000
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < total; i++ {
+					wr.Inf("log", Num("i", i))
+				}
+			}()
+			for i := 1; i <= 3; i++ {
+				time.Sleep(time.Millisecond)
+				_ = os.Rename(name, name+"."+string(rune('0'+i)))
+				require.NoError(t, fl.(Reopener).Reopen())
+			}
+			wg.Wait()
+			wr.Flush(time.Second)
+
+			matches, err := filepath.Glob(name + "*")
+			require.NoError(t, err)
+			assert.Equal(t, total, countLines(t, matches...))
+		})
+
+		t.Run(tc.name+" should rotate to a backup file on demand", func(t *testing.T) {
+			dir := t.TempDir()
+			fl := NewFileWriter(InfoLevel, NewConfig(append(tc.opts, WithFilePath(filepath.Join(dir, "app.log")))...))
+			wr := NewZapLogger(fl)
+			wr.Init(time.Microsecond)
+
+			wr.Inf("before")
+			require.NoError(t, fl.(Reopener).Rotate())
+			wr.Inf("after")
+			wr.Flush(time.Second)
+
+			backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+			require.NoError(t, err)
+			require.Len(t, backups, 1)
+			assert.Equal(t, 1, countLines(t, backups[0]))
+			assert.Equal(t, 1, countLines(t, filepath.Join(dir, "app.log")))
+		})
+	}
+}
+
+// reopenedWriter Writer implementer that record each Reopen call.
+type reopenedWriter struct {
+	plainWriter
+	ch chan struct{}
+}
+
+func (r reopenedWriter) Rotate() error { return nil }
+func (r reopenedWriter) Reopen() error { r.ch <- struct{}{}; return nil }
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	rw := reopenedWriter{plainWriter: NewConsoleWriter(InfoLevel), ch: make(chan struct{}, 1)}
+	stop := ReopenOnSIGHUP(NewConsoleWriter(InfoLevel), NewAsyncWriter(rw))
+	defer stop()
+
+	t.Run("Should reopen wrapped Writer when receiving SIGHUP", func(t *testing.T) {
+		p, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, p.Signal(syscall.SIGHUP))
+
+		select {
+		case <-rw.ch:
+		case <-time.After(time.Second):
+			t.Fatal("Writer is not reopened")
+		}
+	})
+
+	t.Run("Should not reopen after stopped", func(t *testing.T) {
+		ch := make(chan os.Signal, 1)
+		stop := reopenOn(ch, []Writer{rw})
+		stop()
+		stop() // safe to be called more than once
+		ch <- syscall.SIGHUP
+
+		select {
+		case <-rw.ch:
+			t.Fatal("Writer is reopened after stopped")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package apilog
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// NewSampledWriter return Writer implementer that wraps given w, so only the
+// first n logs with the same level and message are written in every tick, then
+// only every mth log after that. Set m to 0 to drop every log after the first
+// n.
+func NewSampledWriter(w Writer, tick time.Duration, n, m int) *SampledWriter {
+	return &SampledWriter{wr: w, tick: tick, first: n, thereafter: m}
+}
+
+// SampledWriter wraps a Writer and sample repetitive logs written to it. The
+// sampling itself is done by each Logger implementation.
+type SampledWriter struct {
+	wr         Writer
+	tick       time.Duration
+	first      int
+	thereafter int
+
+	sampled atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func (s *SampledWriter) Writer() io.Writer       { return s.wr.Writer() }
+func (s *SampledWriter) Output() Output          { return s.wr.Output() }
+func (s *SampledWriter) Level() Level            { return s.wr.Level() }
+func (s *SampledWriter) Wait(dur time.Duration)  { s.wr.Wait(dur) }
+func (s *SampledWriter) Flush(dur time.Duration) { s.wr.Flush(dur) }
+
+// AtomicLevel return the AtomicLevel of the wrapped Writer if any.
+func (s *SampledWriter) AtomicLevel() *AtomicLevel { return atomicLevelOf(s.wr) }
+
+// Sampled return the number of logs that passed the sampling.
+func (s *SampledWriter) Sampled() uint64 { return s.sampled.Load() }
+
+// Dropped return the number of logs dropped by the sampling.
+func (s *SampledWriter) Dropped() uint64 { return s.dropped.Load() }
+
+// record count the sampling decision.
+func (s *SampledWriter) record(dropped bool) {
+	if dropped {
+		s.dropped.Add(1)
+		return
+	}
+	s.sampled.Add(1)
+}
+
+// Unwrap return the wrapped Writer.
+func (s *SampledWriter) Unwrap() Writer { return s.wr }
+
+// samplingOf return the SampledWriter of given w, or of any Writer wrapped by
+// w if any, otherwise nil.
+func samplingOf(w Writer) *SampledWriter {
+	for w != nil {
+		if s, ok := w.(*SampledWriter); ok {
+			return s
+		}
+		u, ok := w.(interface{ Unwrap() Writer })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+	return nil
+}
+
+// sampleCounters number of counters used by the sampler. Logs with different
+// level and message may share the same counter if their hashes collide, the
+// same trade-off zap sampler made.
+const sampleCounters = 4096
+
+// sampler decide whether a log should be dropped the same way zap sampler
+// does.
+type sampler struct {
+	wr       *SampledWriter
+	counters [sampleCounters]sampleCounter
+}
+
+func newSampler(wr *SampledWriter) *sampler {
+	return &sampler{wr: wr}
+}
+
+// drop return true if the log with given backend specific level and message
+// should be dropped at given t.
+func (s *sampler) drop(lvl int, msg string, t time.Time) bool {
+	// fnv-1a hash of the level and message
+	h := uint32(2166136261)
+	h = (h ^ uint32(uint8(lvl))) * 16777619
+	for i := 0; i < len(msg); i++ {
+		h = (h ^ uint32(msg[i])) * 16777619
+	}
+
+	n := s.counters[h%sampleCounters].incCheckReset(t, s.wr.tick)
+	dropped := n > uint64(s.wr.first) &&
+		(s.wr.thereafter <= 0 || (n-uint64(s.wr.first))%uint64(s.wr.thereafter) != 0)
+	s.wr.record(dropped)
+	return dropped
+}
+
+// sampleCounter count logs within a tick.
+type sampleCounter struct {
+	resetAt atomic.Int64
+	counter atomic.Uint64
+}
+
+// incCheckReset increase the counter, or reset it if the tick is already
+// passed.
+func (c *sampleCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAfter := c.resetAt.Load()
+	if resetAfter > tn {
+		return c.counter.Add(1)
+	}
+
+	c.counter.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAfter, tn+tick.Nanoseconds()) {
+		// someone else already reset the counter
+		return c.counter.Add(1)
+	}
+	return 1
+}
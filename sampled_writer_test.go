@@ -0,0 +1,85 @@
+package apilog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSampledWriter(t *testing.T) {
+	t.Run("Should delegate to the wrapped Writer", func(t *testing.T) {
+		wr, obs := NewObserverWriter(WarnLevel, FILE)
+		sw := NewSampledWriter(wr, time.Second, 1, 0)
+		assert.Equal(t, obs, sw.Writer())
+		assert.Equal(t, FILE, sw.Output())
+		assert.Equal(t, WarnLevel, sw.Level())
+		assert.Equal(t, obs.AtomicLevel(), sw.AtomicLevel())
+		assert.Equal(t, wr, sw.Unwrap())
+
+		// just run
+		sw.Wait(-1)
+		sw.Flush(-1)
+	})
+
+	t.Run("Should find the SampledWriter even if it's wrapped", func(t *testing.T) {
+		wr, _ := NewObserverWriter(WarnLevel, FILE)
+		sw := NewSampledWriter(wr, time.Second, 1, 0)
+		assert.Equal(t, sw, samplingOf(sw))
+		assert.Equal(t, sw, samplingOf(NewAsyncWriter(sw)))
+		assert.Nil(t, samplingOf(wr))
+	})
+
+	testCases := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name+" should only write the first n then every mth", func(t *testing.T) {
+			wr, obs := NewObserverWriter(DebugLevel, FILE)
+			sw := NewSampledWriter(wr, time.Minute, 2, 3)
+			lg := tc.fn(sw)
+			lg.Init(time.Microsecond)
+
+			for i := 0; i < 10; i++ {
+				lg.Err("flapping", Num("i", i))
+			}
+			// different message should have its own counter
+			lg.Err("another")
+			// different level as well
+			lg.Wrn("flapping")
+
+			// 1st, 2nd, 5th, 8th
+			require.Equal(t, 6, obs.Len())
+			logs := obs.All()
+			for i, n := range []float64{0, 1, 4, 7} {
+				assert.Equal(t, n, logs[i].Get("i"))
+			}
+			assert.True(t, logs[4].EqualMsg("another"))
+			assert.True(t, logs[5].EqualLevel(WarnLevel))
+			assert.Equal(t, uint64(6), sw.Sampled())
+			assert.Equal(t, uint64(6), sw.Dropped())
+		})
+
+		t.Run(tc.name+" should reset after every tick", func(t *testing.T) {
+			wr, obs := NewObserverWriter(DebugLevel, FILE)
+			sw := NewSampledWriter(wr, 20*time.Millisecond, 1, 0)
+			lg := tc.fn(sw)
+			lg.Init(time.Microsecond)
+			lg = lg.With(String("hello", "world"))
+
+			lg.Inf("flapping")
+			lg.Inf("flapping")
+			time.Sleep(30 * time.Millisecond)
+			lg.Inf("flapping")
+
+			require.Equal(t, 2, obs.Len())
+			assert.Equal(t, "world", obs.All()[1].Get("hello"))
+			assert.Equal(t, uint64(1), sw.Dropped())
+		})
+	}
+}
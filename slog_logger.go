@@ -1,7 +1,9 @@
 package apilog
 
 import (
+	"context"
 	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -13,8 +15,9 @@ func NewSlogLogger(wr ...Writer) Logger {
 }
 
 type slogLogger struct {
-	log *multiSlog
-	wr  []Writer
+	log  *multiSlog
+	wr   []Writer
+	opts loggerOpts
 }
 
 func (s *slogLogger) clone() *slogLogger {
@@ -27,12 +30,12 @@ func (s *slogLogger) Init(dur time.Duration) {
 	for _, w := range s.wr {
 		switch w.Output() {
 		case CONSOLE:
-			opt := &slog.HandlerOptions{Level: toSlogLevel(w.Level())}
-			slogs.loggers = append(slogs.loggers, slog.New(slog.NewTextHandler(w.Writer(), opt)))
+			opt := &slog.HandlerOptions{Level: toSlogLeveler(w), ReplaceAttr: replaceSlogLevel}
+			slogs.loggers = append(slogs.loggers, slog.New(toSlogSampler(slog.NewTextHandler(w.Writer(), opt), w)))
 
-		case FILE, NEWRELIC:
-			opt := &slog.HandlerOptions{Level: toSlogLevel(w.Level())}
-			slogs.loggers = append(slogs.loggers, slog.New(slog.NewJSONHandler(w.Writer(), opt)))
+		case FILE, NEWRELIC, SYSLOG, HTTP, LOKI, OTLP, ELASTICSEARCH, FLUENT, GELF, SPLUNK, DATADOG, KAFKA, JOURNALD, NETWORK:
+			opt := &slog.HandlerOptions{Level: toSlogLeveler(w), ReplaceAttr: replaceSlogLevel}
+			slogs.loggers = append(slogs.loggers, slog.New(toSlogSampler(slog.NewJSONHandler(w.Writer(), opt), w)))
 		}
 		w.Wait(dur)
 	}
@@ -45,6 +48,30 @@ func (s *slogLogger) Flush(dur time.Duration) {
 	}
 }
 
+func (s *slogLogger) Enabled(lvl Level) bool {
+	if s.log == nil {
+		return false
+	}
+	return s.log.Enabled(toSlogLevel(lvl))
+}
+
+func (s *slogLogger) WithOptions(opts ...LoggerOpt) Logger {
+	if len(opts) == 0 {
+		return s
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// clone it, so on every WithOptions method call does not affect the parent logger
+	clone := s.clone()
+	clone.opts = s.opts.apply(opts)
+
+	// then reassign to singleton
+	singletonLogger = clone
+
+	return clone
+}
+
 func (s *slogLogger) With(pr ...Log) Logger {
 	if len(pr) == 0 {
 		return s
@@ -54,7 +81,7 @@ func (s *slogLogger) With(pr ...Log) Logger {
 
 	// clone it, so on every With method call does not affect the parent logger
 	clone := s.clone()
-	clone.log = clone.log.With(toSlogAttr(pr)...)
+	clone.log = clone.log.With(toSlogAttr(s.opts.redactor.redact(pr))...)
 
 	// then reassign to singleton
 	singletonLogger = clone
@@ -71,7 +98,7 @@ func (s *slogLogger) Group(key string, pr ...Log) Logger {
 
 	// clone it, so on every Group method call does not affect the parent logger
 	clone := s.clone()
-	clone.log = clone.log.Group(key, toSlogAttr(pr)...)
+	clone.log = clone.log.Group(key, toSlogAttr(s.opts.redactor.redact(pr))...)
 
 	// then reassign to singleton
 	singletonLogger = clone
@@ -79,41 +106,96 @@ func (s *slogLogger) Group(key string, pr ...Log) Logger {
 	return clone
 }
 
+func (s *slogLogger) Trc(msg string, pr ...Log) {
+	s.log.Log(slogLevelTrace, msg, s.attrs(TraceLevel, pr)...)
+}
+
 func (s *slogLogger) Dbg(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		s.log.Debug(msg, toSlogAttr(pr)...)
-		return
-	}
-	s.log.Debug(msg)
+	s.log.Debug(msg, s.attrs(DebugLevel, pr)...)
 }
 
 func (s *slogLogger) Inf(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		s.log.Info(msg, toSlogAttr(pr)...)
-		return
-	}
-	s.log.Info(msg)
+	s.log.Info(msg, s.attrs(InfoLevel, pr)...)
 }
 
 func (s *slogLogger) Wrn(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		s.log.Warn(msg, toSlogAttr(pr)...)
-		return
-	}
-	s.log.Warn(msg, toSlogAttr(pr)...)
+	s.log.Warn(msg, s.attrs(WarnLevel, pr)...)
 }
 
 func (s *slogLogger) Err(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		s.log.Error(msg, toSlogAttr(pr)...)
-		return
+	s.log.Error(msg, s.attrs(ErrorLevel, pr)...)
+}
+
+func (s *slogLogger) Pnc(msg string, pr ...Log) {
+	s.log.Log(slogLevelPanic, msg, s.attrs(PanicLevel, pr)...)
+	s.Flush(s.opts.exitTimeout())
+	panic(msg)
+}
+
+func (s *slogLogger) Ftl(msg string, pr ...Log) {
+	s.log.Log(slogLevelFatal, msg, s.attrs(FatalLevel, pr)...)
+	s.Flush(s.opts.exitTimeout())
+	exit(1)
+}
+
+// attrs transform given Log(s) to slog attributes and also append caller and
+// stack trace if enabled. Return nil if no one will write logs at given lvl.
+// Should only be called directly by the Logger methods, otherwise the
+// reported caller would be wrong.
+func (s *slogLogger) attrs(lvl Level, pr []Log) []any {
+	// no need to transform anything if no one will write it
+	if !s.log.Enabled(toSlogLevel(lvl)) {
+		return nil
+	}
+	attrs := toSlogAttr(s.opts.redactor.redact(pr))
+	if !s.opts.caller && !s.opts.stackEnabled(lvl) {
+		return attrs
+	}
+	// skip this method and the Logger method
+	skip := 2 + s.opts.callerSkip
+	if s.opts.caller {
+		attrs = append(attrs, slog.String("caller", callerOf(skip)))
+	}
+	if s.opts.stackEnabled(lvl) {
+		attrs = append(attrs, slog.String("stacktrace", stacktraceOf(skip)))
+	}
+	return attrs
+}
+
+// custom slog levels that are not supported by slog out of the box.
+const (
+	slogLevelTrace = slog.LevelDebug - 4
+	slogLevelPanic = slog.LevelError + 4
+	slogLevelFatal = slog.LevelError + 8
+)
+
+// slogLevelNames name of the custom slog levels, so it's rendered the same way
+// zap does instead of e.g. ERROR+4.
+var slogLevelNames = map[slog.Level]string{
+	slogLevelTrace: "TRACE",
+	slogLevelPanic: "PANIC",
+	slogLevelFatal: "FATAL",
+}
+
+// replaceSlogLevel used as slog.HandlerOptions ReplaceAttr to render the name
+// of the custom slog levels.
+func replaceSlogLevel(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
+		return a
 	}
-	s.log.Error(msg)
+	if lvl, ok := a.Value.Any().(slog.Level); ok {
+		if name, ok := slogLevelNames[lvl]; ok {
+			a.Value = slog.StringValue(name)
+		}
+	}
+	return a
 }
 
 // toSlogLevel transform log Level to slog level.
 func toSlogLevel(lvl Level) slog.Level {
 	switch lvl {
+	case TraceLevel:
+		return slogLevelTrace
 	case DebugLevel:
 		return slog.LevelDebug
 	case InfoLevel:
@@ -122,14 +204,65 @@ func toSlogLevel(lvl Level) slog.Level {
 		return slog.LevelWarn
 	case ErrorLevel:
 		return slog.LevelError
+	case PanicLevel:
+		return slogLevelPanic
+	case FatalLevel:
+		return slogLevelFatal
 	}
 	return -1
 }
 
+// toSlogLeveler return the slog LevelVar of given w if its Level can be
+// changed at runtime, otherwise just use the fixed Level.
+func toSlogLeveler(w Writer) slog.Leveler {
+	if al := atomicLevelOf(w); al != nil {
+		return al.slog
+	}
+	return toSlogLevel(w.Level())
+}
+
+// toSlogSampler wrap given h with slogSampler if given w is SampledWriter,
+// otherwise return as is.
+func toSlogSampler(h slog.Handler, w Writer) slog.Handler {
+	sw := samplingOf(w)
+	if sw == nil {
+		return h
+	}
+	return &slogSampler{h: h, s: newSampler(sw)}
+}
+
+// slogSampler slog.Handler that sample repetitive logs before passing them to
+// the wrapped slog.Handler, equivalent to zap sampler.
+type slogSampler struct {
+	h slog.Handler
+	s *sampler
+}
+
+func (s *slogSampler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return s.h.Enabled(ctx, lvl)
+}
+
+func (s *slogSampler) Handle(ctx context.Context, r slog.Record) error {
+	// same as zap, only sample from debug until fatal level
+	if r.Level >= slog.LevelDebug && r.Level <= slogLevelFatal && s.s.drop(int(r.Level), r.Message, r.Time) {
+		return nil
+	}
+	return s.h.Handle(ctx, r)
+}
+
+func (s *slogSampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &slogSampler{h: s.h.WithAttrs(attrs), s: s.s}
+}
+
+func (s *slogSampler) WithGroup(name string) slog.Handler {
+	return &slogSampler{h: s.h.WithGroup(name), s: s.s}
+}
+
 // toSlogAttr transform Log to specific slog field/attribute.
 func toSlogAttr(pr []Log) []any {
 	var attrs []any
 	for _, p := range pr {
+		p = p.resolve()
 		switch p.typ {
 		case StringType:
 			attrs = append(attrs, slog.String(p.key, p.str))
@@ -143,11 +276,100 @@ func toSlogAttr(pr []Log) []any {
 			attrs = append(attrs, slog.Any(p.key, p.any))
 		case ErrorType:
 			attrs = append(attrs, slog.Any(p.key, p.err))
+		case Int64Type:
+			attrs = append(attrs, slog.Int64(p.key, p.i64))
+		case Uint64Type:
+			attrs = append(attrs, slog.Uint64(p.key, p.u64))
+		case DurationType:
+			attrs = append(attrs, slog.String(p.key, time.Duration(p.i64).String()))
+		case TimeType:
+			attrs = append(attrs, slog.String(p.key, p.any.(time.Time).Format(time.RFC3339Nano)))
+		case StringsType, IntsType, StringerType:
+			attrs = append(attrs, slog.Any(p.key, p.any))
+		case BytesType:
+			attrs = append(attrs, slog.String(p.key, string(p.any.([]byte))))
+		case ObjectType:
+			attrs = append(attrs, toSlogObject(p.key, p.any.(ObjectMarshaler))...)
+		case SecretType:
+			attrs = append(attrs, slog.String(p.key, MaskFull(p.str)))
 		}
 	}
 	return attrs
 }
 
+// LogValue implement slog.LogValuer, so String is only called when the log is
+// actually written.
+func (s safeStringer) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+// toSlogObject transform ObjectMarshaler to lazily marshaled slog group
+// attribute. If failed, the error is added as '<key>Error' attribute the same
+// way zap does.
+func toSlogObject(key string, v ObjectMarshaler) []any {
+	o := &slogObject{v: v}
+	return []any{slog.Any(key, o), slog.Any(key+"Error", slogObjectError{o})}
+}
+
+// slogObject implement slog.LogValuer, so MarshalLogObject is only called
+// once when the log is actually written.
+type slogObject struct {
+	v    ObjectMarshaler
+	once sync.Once
+	enc  slogObjectEncoder
+	err  error
+}
+
+func (o *slogObject) marshal() {
+	o.once.Do(func() { o.err = o.v.MarshalLogObject(&o.enc) })
+}
+
+func (o *slogObject) LogValue() slog.Value {
+	o.marshal()
+	return slog.GroupValue(o.enc.attrs...)
+}
+
+// slogObjectError implement slog.LogValuer that resolve to the error of
+// marshaling its slogObject, or empty group that is omitted by the handler if
+// succeeded.
+type slogObjectError struct {
+	o *slogObject
+}
+
+func (e slogObjectError) LogValue() slog.Value {
+	if e.o.marshal(); e.o.err != nil {
+		return slog.StringValue(e.o.err.Error())
+	}
+	return slog.GroupValue()
+}
+
+// slogObjectEncoder implement ObjectEncoder by collecting slog attributes.
+type slogObjectEncoder struct {
+	attrs []slog.Attr
+}
+
+func (s *slogObjectEncoder) add(a slog.Attr)              { s.attrs = append(s.attrs, a) }
+func (s *slogObjectEncoder) AddString(k, v string)        { s.add(slog.String(k, v)) }
+func (s *slogObjectEncoder) AddNum(k string, v int)       { s.add(slog.Int(k, v)) }
+func (s *slogObjectEncoder) AddInt64(k string, v int64)   { s.add(slog.Int64(k, v)) }
+func (s *slogObjectEncoder) AddUint64(k string, v uint64) { s.add(slog.Uint64(k, v)) }
+func (s *slogObjectEncoder) AddFloat(k string, v float64) { s.add(slog.Float64(k, v)) }
+func (s *slogObjectEncoder) AddBool(k string, v bool)     { s.add(slog.Bool(k, v)) }
+func (s *slogObjectEncoder) AddDuration(k string, v time.Duration) {
+	s.add(slog.String(k, v.String()))
+}
+func (s *slogObjectEncoder) AddTime(k string, v time.Time) {
+	s.add(slog.String(k, v.Format(time.RFC3339Nano)))
+}
+func (s *slogObjectEncoder) AddObject(k string, v ObjectMarshaler) error {
+	enc := new(slogObjectEncoder)
+	err := v.MarshalLogObject(enc)
+	s.add(slog.Attr{Key: k, Value: slog.GroupValue(enc.attrs...)})
+	return err
+}
+func (s *slogObjectEncoder) AddAny(k string, v any) error {
+	s.add(slog.Any(k, v))
+	return nil
+}
+
 // multiSlog add support to write logs to multiple slog.Logger.
 type multiSlog struct {
 	loggers []*slog.Logger
@@ -169,6 +391,17 @@ func (m *multiSlog) Group(key string, args ...any) *multiSlog {
 	return &multiSlog{loggers: clone}
 }
 
+// Enabled return true if at least one of the loggers will write logs at given
+// lvl.
+func (m *multiSlog) Enabled(lvl slog.Level) bool {
+	for _, log := range m.loggers {
+		if log.Enabled(context.Background(), lvl) {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *multiSlog) Debug(msg string, args ...any) {
 	for _, log := range m.loggers {
 		log.Debug(msg, args...)
@@ -192,3 +425,9 @@ func (m *multiSlog) Error(msg string, args ...any) {
 		log.Error(msg, args...)
 	}
 }
+
+func (m *multiSlog) Log(lvl slog.Level, msg string, args ...any) {
+	for _, log := range m.loggers {
+		log.Log(context.Background(), lvl, msg, args...)
+	}
+}
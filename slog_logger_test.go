@@ -2,6 +2,7 @@ package apilog
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"log/slog"
 	"strings"
@@ -18,6 +19,11 @@ func TestToSlogLevel(t *testing.T) {
 		sample Level
 		expect slog.Level
 	}{
+		{
+			name:   "Trace level",
+			sample: TraceLevel,
+			expect: slogLevelTrace,
+		},
 		{
 			name:   "Debug level",
 			sample: DebugLevel,
@@ -38,6 +44,16 @@ func TestToSlogLevel(t *testing.T) {
 			sample: ErrorLevel,
 			expect: slog.LevelError,
 		},
+		{
+			name:   "Panic level",
+			sample: PanicLevel,
+			expect: slogLevelPanic,
+		},
+		{
+			name:   "Fatal level",
+			sample: FatalLevel,
+			expect: slogLevelFatal,
+		},
 		{
 			name:   "Unrecognized level",
 			sample: -1,
@@ -88,6 +104,41 @@ func TestToSlogAttr(t *testing.T) {
 			sample: Error(errors.New("oops")),
 			expect: []any{slog.Any("error", errors.New("oops"))},
 		},
+		{
+			name:   "Int64 attribute",
+			sample: Int64("id", 1<<40),
+			expect: []any{slog.Int64("id", 1<<40)},
+		},
+		{
+			name:   "Uint64 attribute",
+			sample: Uint64("count", 1<<63),
+			expect: []any{slog.Uint64("count", 1<<63)},
+		},
+		{
+			name:   "Duration (string) attribute",
+			sample: Duration("took", time.Second),
+			expect: []any{slog.String("took", "1s")},
+		},
+		{
+			name:   "Time (string) attribute",
+			sample: Time("at", time.Date(2024, 8, 28, 7, 59, 13, 0, time.UTC)),
+			expect: []any{slog.String("at", "2024-08-28T07:59:13Z")},
+		},
+		{
+			name:   "Strings attribute",
+			sample: Strings("tags", []string{"a"}),
+			expect: []any{slog.Any("tags", []string{"a"})},
+		},
+		{
+			name:   "Ints attribute",
+			sample: Ints("ids", []int{1}),
+			expect: []any{slog.Any("ids", []int{1})},
+		},
+		{
+			name:   "Bytes (string) attribute",
+			sample: Bytes("body", []byte("hello")),
+			expect: []any{slog.String("body", "hello")},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -148,7 +199,8 @@ func TestNewSlogLogger(t *testing.T) {
 
 		// start logging message
 		wr = wr.With(String("hello", "world"))
-		wr = wr.With() // just to increase code coverage
+		wr = wr.With()      // just to increase code coverage
+		wr.Trc("trace log") // should not be written
 		wr.Dbg("debug log", Num("number", 11))
 		wr.Inf("info log", Bool("ok", true))
 		wr.Wrn("warning log", Float("scale", 1.2))
@@ -189,3 +241,16 @@ func TestNewSlogLogger(t *testing.T) {
 		wr.Flush(time.Microsecond)
 	})
 }
+
+func TestReplaceSlogLevel(t *testing.T) {
+	var buf = new(bytes.Buffer)
+	opt := &slog.HandlerOptions{Level: slogLevelTrace, ReplaceAttr: replaceSlogLevel}
+	sl := slog.New(slog.NewJSONHandler(buf, opt))
+	sl.Log(context.Background(), slogLevelTrace, "trace", slog.Group("req", slog.String("level", "raw")))
+	sl.Debug("debug")
+	msg := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	require.Len(t, msg, 2)
+	assert.Contains(t, msg[0], `"level":"TRACE","msg":"trace","req":{"level":"raw"}`)
+	assert.Contains(t, msg[1], `"level":"DEBUG","msg":"debug"`)
+}
@@ -0,0 +1,259 @@
+package apilog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// splunkEventPath path of splunk HTTP Event Collector event endpoint.
+const splunkEventPath = "/services/collector/event"
+
+// NewSplunkWriter return Writer implementer that send logs in batches to
+// splunk HTTP Event Collector by given Config and set given lvl as the log
+// Level. Each log is wrapped in HEC event envelope whose time is the log
+// time. Error response is reported as *SplunkError. Batching, retry, headers
+// and error handling are configured using the same options as NewHTTPWriter
+// e.g. WithHTTPBatchSize, except the URL.
+func NewSplunkWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	s := &splunkEncoder{cnf: cnf.splunk}
+	// set default value
+	if s.cnf.host == "" {
+		s.cnf.host, _ = os.Hostname()
+	}
+	if s.cnf.sourcetype == "" {
+		s.cnf.sourcetype = "_json"
+	}
+
+	hc := cnf.http
+	hc.url = endpointURL(cnf.splunk.url, "https://localhost:8088", splunkEventPath)
+	hc.contentType = "application/json"
+	hc.header = hc.header.Clone()
+	if hc.header == nil {
+		hc.header = make(http.Header)
+	}
+	if cnf.splunk.token != "" {
+		hc.header.Set("Authorization", "Splunk "+cnf.splunk.token)
+	}
+
+	h := newHTTPBatcher(SPLUNK, lvl, hc, s.encode)
+	h.statusErr = splunkStatusErr
+	if s.cnf.ack > 0 {
+		// ack require every request to be sent within a channel
+		h.cnf.header.Set("X-Splunk-Request-Channel", newChannelID())
+		a := &splunkAck{h: h, url: splunkAckURL(hc.url), timeout: s.cnf.ack}
+		h.partial = a.wait
+	}
+	return h
+}
+
+// SplunkError error response of splunk HTTP Event Collector.
+type SplunkError struct {
+	Status int    // Status HTTP status code of the response
+	Code   int    // Code HEC status code e.g. 4 for invalid token
+	Text   string // Text human readable status message
+}
+
+func (s *SplunkError) Error() string {
+	return fmt.Sprintf("splunk HEC error code %d (status %d): %s", s.Code, s.Status, s.Text)
+}
+
+// splunkResponse response of splunk HTTP Event Collector.
+type splunkResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+// splunkStatusErr return *SplunkError from given response body, or the
+// generic error if it's not HEC response.
+func splunkStatusErr(status int, body []byte) error {
+	var res splunkResponse
+	if err := json.Unmarshal(body, &res); err != nil || res.Text == "" {
+		return fmt.Errorf("unexpected status code %d: %s", status, bytes.TrimSpace(body))
+	}
+	return &SplunkError{Status: status, Code: res.Code, Text: res.Text}
+}
+
+// splunkEncoder encode the batch as HEC events.
+type splunkEncoder struct {
+	cnf SplunkConfig
+}
+
+// splunkEvent HEC event envelope.
+type splunkEvent struct {
+	Time       json.Number       `json:"time"`
+	Host       string            `json:"host,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	SourceType string            `json:"sourcetype,omitempty"`
+	Index      string            `json:"index,omitempty"`
+	Event      json.RawMessage   `json:"event"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+// encode given entries as newline delimited HEC events. The log is used as
+// the event as is if it's a JSON object, otherwise as string.
+func (s *splunkEncoder) encode(entries [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		ev := splunkEvent{
+			Host:       s.cnf.host,
+			Source:     s.cnf.source,
+			SourceType: s.cnf.sourcetype,
+			Index:      s.cnf.index,
+			Event:      e,
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal(e, &fields); err != nil || fields == nil {
+			b, _ := json.Marshal(string(e))
+			ev.Event = b
+		}
+		ev.Time = unixSeconds(timeOf(fields))
+		for k, v := range s.cnf.static {
+			if ev.Fields == nil {
+				ev.Fields = make(map[string]string)
+			}
+			ev.Fields[k] = v
+		}
+		for _, f := range s.cnf.fields {
+			if v, ok := fieldOf(fields, f); ok {
+				if ev.Fields == nil {
+					ev.Fields = make(map[string]string)
+				}
+				ev.Fields[f] = v
+			}
+		}
+
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// splunkAck wait for each batch to be acknowledged by splunk HTTP Event
+// Collector, meaning it's indexed.
+type splunkAck struct {
+	h       *httpBatcher
+	url     string
+	timeout time.Duration
+}
+
+// wait poll the ack status of the batch whose response is given resp until
+// it's acknowledged. Every entries are returned to be resent if not
+// acknowledged within the timeout, while error response of the ack endpoint
+// is reported immediately without resending, since the batch may already be
+// indexed.
+func (a *splunkAck) wait(ctx context.Context, entries [][]byte, resp []byte) ([][]byte, int, error) {
+	var res splunkResponse
+	if err := json.Unmarshal(resp, &res); err != nil || res.AckID == nil {
+		// nothing to wait for
+		return nil, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	// avoid busy polling on short timeout
+	poll := max(min(time.Second, a.timeout/10), 10*time.Millisecond)
+	for {
+		acked, err := a.acked(ctx, *res.AckID)
+		if err == nil && acked {
+			return nil, 0, nil
+		}
+		var rErr *splunkAckRejected
+		if errors.As(err, &rErr) {
+			return nil, len(entries), err
+		}
+
+		t := time.NewTimer(poll)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return entries, 0, fmt.Errorf("ack %d is not received within %s", *res.AckID, a.timeout)
+		}
+	}
+}
+
+// acked return true if given ack id is acknowledged.
+func (a *splunkAck) acked(ctx context.Context, id int64) (bool, error) {
+	body, _ := json.Marshal(map[string][]int64{"acks": {id}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range a.h.cnf.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.h.cnf.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, &splunkAckRejected{id: id, err: splunkStatusErr(resp.StatusCode, b)}
+	}
+
+	var res struct {
+		Acks map[string]bool `json:"acks"`
+	}
+	if err = json.Unmarshal(b, &res); err != nil {
+		return false, err
+	}
+	return res.Acks[strconv.FormatInt(id, 10)], nil
+}
+
+// splunkAckRejected error response of the ack endpoint.
+type splunkAckRejected struct {
+	id  int64
+	err error
+}
+
+func (e *splunkAckRejected) Error() string {
+	return fmt.Sprintf("ack %d is rejected: %v", e.id, e.err)
+}
+
+func (e *splunkAckRejected) Unwrap() error { return e.err }
+
+// splunkAckURL return the ack endpoint URL next to given event endpoint URL.
+func splunkAckURL(event string) string {
+	u, err := url.Parse(event)
+	if err != nil {
+		return event
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/event") + "/ack"
+	return u.String()
+}
+
+// newChannelID return random UUID v4 used as HEC channel.
+func newChannelID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,258 @@
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeEvents decode given newline delimited HEC events.
+func decodeEvents(t *testing.T, b []byte) []map[string]any {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	var events []map[string]any
+	for sc.Scan() {
+		var ev map[string]any
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &ev))
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestNewSplunkWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewSplunkWriter(WarnLevel, nil)
+		defer wr.Flush(-1)
+		assert.Equal(t, SPLUNK, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "https://localhost:8088/services/collector/event", h.cnf.url)
+		assert.Empty(t, h.cnf.header.Get("Authorization"))
+		assert.Empty(t, h.cnf.header.Get("X-Splunk-Request-Channel"))
+		assert.Nil(t, h.partial)
+	})
+
+	t.Run("Given token and ack should set the auth header and request channel", func(t *testing.T) {
+		wr := NewSplunkWriter(InfoLevel, NewConfig(WithSplunkToken("secret"), WithSplunkAck(time.Second)))
+		defer wr.Flush(-1)
+
+		h := wr.(*httpBatcher)
+		assert.Equal(t, "Splunk secret", h.cnf.header.Get("Authorization"))
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, h.cnf.header.Get("X-Splunk-Request-Channel"))
+		assert.NotNil(t, h.partial)
+	})
+}
+
+func TestSplunkEncoder(t *testing.T) {
+	t.Run("Should wrap each log in event envelope using the log time", func(t *testing.T) {
+		s := &splunkEncoder{cnf: SplunkConfig{
+			host:       "host",
+			source:     "api",
+			sourcetype: "_json",
+			index:      "main",
+			fields:     []string{"app", "ctx.env", "missing"},
+			static:     map[string]string{"region": "eu"},
+		}}
+		b, err := s.encode([][]byte{
+			[]byte(`{"level":"INFO","time":"2024-08-28T07:59:13.259+07:00","msg":"hello","app":"api","ctx":{"env":"prod"}}`),
+			[]byte(`not a json`),
+		})
+		require.NoError(t, err)
+
+		events := decodeEvents(t, b)
+		require.Len(t, events, 2)
+		assert.Equal(t, 1724806753.259, events[0]["time"])
+		assert.Equal(t, "host", events[0]["host"])
+		assert.Equal(t, "api", events[0]["source"])
+		assert.Equal(t, "_json", events[0]["sourcetype"])
+		assert.Equal(t, "main", events[0]["index"])
+		assert.Equal(t, "hello", events[0]["event"].(map[string]any)["msg"])
+		assert.Equal(t, map[string]any{"app": "api", "ctx.env": "prod", "region": "eu"}, events[0]["fields"])
+		assert.Equal(t, "not a json", events[1]["event"])
+	})
+}
+
+func TestSplunkAckURL(t *testing.T) {
+	assert.Equal(t, "https://splunk:8088/services/collector/ack", splunkAckURL("https://splunk:8088/services/collector/event"))
+	assert.Equal(t, "https://splunk:8088/services/collector/ack", splunkAckURL("https://splunk:8088/services/collector"))
+}
+
+func TestSplunkWriter(t *testing.T) {
+	t.Run("Should send the batch to the event endpoint", func(t *testing.T) {
+		srv, ch := newHTTPServer(t, func(int) int { return http.StatusOK })
+		wr := NewSplunkWriter(InfoLevel, NewConfig(WithSplunkURL(srv.URL), WithSplunkToken("secret")))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"first"}`))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"second"}`))
+		wr.Flush(time.Second)
+
+		req := receiveRequest(t, ch)
+		assert.Equal(t, "Splunk secret", req.header.Get("Authorization"))
+		events := decodeEvents(t, req.body)
+		require.Len(t, events, 2)
+		assert.Equal(t, "second", events[1]["event"].(map[string]any)["msg"])
+	})
+
+	t.Run("Given HEC error response should report it as SplunkError", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+		}))
+		defer srv.Close()
+
+		var reported error
+		wr := NewSplunkWriter(InfoLevel, NewConfig(
+			WithSplunkURL(srv.URL),
+			WithHTTPErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		var sErr *SplunkError
+		require.True(t, errors.As(reported, &sErr))
+		assert.Equal(t, &SplunkError{Status: http.StatusForbidden, Code: 4, Text: "Invalid token"}, sErr)
+		assert.EqualError(t, reported, "failed to send 1 logs: splunk HEC error code 4 (status 403): Invalid token")
+	})
+
+	t.Run("Given server busy should retry and report it as SplunkError after all retries", func(t *testing.T) {
+		var n atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			n.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"text":"Server is busy","code":9}`))
+		}))
+		defer srv.Close()
+
+		var reported error
+		wr := NewSplunkWriter(InfoLevel, NewConfig(
+			WithSplunkURL(srv.URL),
+			WithHTTPRetry(2, time.Millisecond),
+			WithHTTPErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		assert.Equal(t, int32(3), n.Load())
+		var sErr *SplunkError
+		require.True(t, errors.As(reported, &sErr))
+		assert.Equal(t, 9, sErr.Code)
+	})
+
+	t.Run("Given ack enabled should wait until the batch is acknowledged", func(t *testing.T) {
+		var mu sync.Mutex
+		var events, polls int
+		var channels []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			channels = append(channels, r.Header.Get("X-Splunk-Request-Channel"))
+
+			switch r.URL.Path {
+			case splunkEventPath:
+				gz, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+				_, _ = io.Copy(io.Discard, gz)
+				events++
+				_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":7}`))
+			case "/services/collector/ack":
+				var req map[string][]int64
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, []int64{7}, req["acks"])
+				polls++
+				// acknowledged on the third poll
+				_, _ = w.Write([]byte(`{"acks":{"7":` + strconv.FormatBool(polls >= 3) + `}}`))
+			}
+		}))
+		defer srv.Close()
+
+		var reported error
+		wr := NewSplunkWriter(InfoLevel, NewConfig(
+			WithSplunkURL(srv.URL),
+			WithSplunkAck(100*time.Millisecond),
+			WithHTTPErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, reported)
+		assert.Equal(t, 1, events)
+		assert.Equal(t, 3, polls)
+		for _, c := range channels {
+			assert.Equal(t, channels[0], c, "should use the same channel")
+		}
+	})
+
+	t.Run("Given batch that is not acknowledged within the timeout should resend it", func(t *testing.T) {
+		var events, polls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == splunkEventPath {
+				events.Add(1)
+				_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":1}`))
+				return
+			}
+			polls.Add(1)
+			_, _ = w.Write([]byte(`{"acks":{"1":false}}`))
+		}))
+		defer srv.Close()
+
+		var reported error
+		wr := NewSplunkWriter(InfoLevel, NewConfig(
+			WithSplunkURL(srv.URL),
+			WithSplunkAck(20*time.Millisecond),
+			WithHTTPRetry(1, time.Millisecond),
+			WithHTTPErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		wr.Flush(time.Second)
+
+		assert.Equal(t, int32(2), events.Load())
+		// polled at most every 10ms within the 20ms timeout of each event
+		assert.LessOrEqual(t, polls.Load(), int32(6))
+		assert.EqualError(t, reported, "failed to send 1 logs: ack 1 is not received within 20ms")
+	})
+	t.Run("Given error response of the ack endpoint should report it immediately without resending", func(t *testing.T) {
+		var events atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == splunkEventPath {
+				events.Add(1)
+				_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":1}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"text":"ACK is disabled","code":14}`))
+		}))
+		defer srv.Close()
+
+		var reported error
+		wr := NewSplunkWriter(InfoLevel, NewConfig(
+			WithSplunkURL(srv.URL),
+			WithSplunkAck(time.Minute),
+			WithHTTPRetry(1, time.Millisecond),
+			WithHTTPErrorHandler(func(err error) { reported = err }),
+		))
+		_, _ = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		start := time.Now()
+		wr.Flush(time.Second)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(1), events.Load())
+		assert.EqualError(t, reported, "failed to send 1 logs: ack 1 is rejected: splunk HEC error code 14 (status 400): ACK is disabled")
+		var sErr *SplunkError
+		require.ErrorAs(t, reported, &sErr)
+		assert.Equal(t, 14, sErr.Code)
+	})
+}
@@ -0,0 +1,304 @@
+package apilog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFacility define the syslog facility the logs are sent as.
+type SyslogFacility int8
+
+const (
+	SyslogKern     SyslogFacility = iota // SyslogKern kernel messages
+	SyslogUser                           // SyslogUser user-level messages
+	SyslogMail                           // SyslogMail mail system
+	SyslogDaemon                         // SyslogDaemon system daemons
+	SyslogAuth                           // SyslogAuth security/authorization messages
+	SyslogSyslog                         // SyslogSyslog messages generated internally by syslogd
+	SyslogLpr                            // SyslogLpr line printer subsystem
+	SyslogNews                           // SyslogNews network news subsystem
+	SyslogUucp                           // SyslogUucp UUCP subsystem
+	SyslogCron                           // SyslogCron clock daemon
+	SyslogAuthPriv                       // SyslogAuthPriv security/authorization messages
+	SyslogFtp                            // SyslogFtp FTP daemon
+)
+
+const (
+	SyslogLocal0 SyslogFacility = iota + 16 // SyslogLocal0 local use 0
+	SyslogLocal1                            // SyslogLocal1 local use 1
+	SyslogLocal2                            // SyslogLocal2 local use 2
+	SyslogLocal3                            // SyslogLocal3 local use 3
+	SyslogLocal4                            // SyslogLocal4 local use 4
+	SyslogLocal5                            // SyslogLocal5 local use 5
+	SyslogLocal6                            // SyslogLocal6 local use 6
+	SyslogLocal7                            // SyslogLocal7 local use 7
+)
+
+// SyslogFraming define how each syslog message is delimited when sent over
+// stream based network e.g. tcp, tls and unix. Not used by datagram based
+// network e.g. udp and unixgram, since each message is sent as a datagram.
+type SyslogFraming int8
+
+const (
+	OctetCounting  SyslogFraming = iota // OctetCounting prefix each message with its length as in RFC 6587
+	NewlineFraming                      // NewlineFraming terminate each message with a newline
+)
+
+// syslog severities as in RFC 5424.
+const (
+	syslogEmergency = iota
+	syslogAlert
+	syslogCritical
+	syslogError
+	syslogWarning
+	syslogNotice
+	syslogInfo
+	syslogDebug
+)
+
+// toSyslogSeverity map given Level to syslog severity. Unknown Level is
+// treated as notice.
+func toSyslogSeverity(lvl Level) int {
+	switch lvl {
+	case TraceLevel, DebugLevel:
+		return syslogDebug
+	case InfoLevel:
+		return syslogInfo
+	case WarnLevel:
+		return syslogWarning
+	case ErrorLevel:
+		return syslogError
+	case PanicLevel:
+		return syslogCritical
+	case FatalLevel:
+		return syslogAlert
+	}
+	return syslogNotice
+}
+
+// NewSyslogWriter return Writer implementer that send logs to syslog collector
+// using RFC 5424 format by given Config and set given lvl as the log Level.
+// Connect lazily and reconnect once on each failed write, so the collector
+// does not have to be available yet. Write fail fast without dialing while
+// waiting for the backoff of the last failed dial.
+func NewSyslogWriter(lvl Level, cnf *Config) Writer {
+	if cnf == nil {
+		cnf = &Config{}
+	}
+
+	s := &syslogOutput{
+		lvl:     NewAtomicLevel(lvl),
+		cnf:     cnf.syslog,
+		pid:     strconv.Itoa(os.Getpid()),
+		timeout: writeTimeout,
+	}
+	// set default value
+	if s.cnf.network == "" {
+		s.cnf.network = "udp"
+	}
+	if s.cnf.addr == "" {
+		s.cnf.addr = "localhost:514"
+	}
+	if s.cnf.facility == SyslogKern {
+		s.cnf.facility = SyslogUser
+	}
+	if s.cnf.app == "" {
+		s.cnf.app = syslogField(filepath.Base(os.Args[0]), 48)
+	}
+	if s.cnf.host == "" {
+		s.cnf.host, _ = os.Hostname()
+		s.cnf.host = syslogField(s.cnf.host, 255)
+	}
+	return s
+}
+
+type syslogOutput struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	redial  redialer
+	timeout time.Duration // maximum time to write each message
+	cnf     SyslogConfig
+	lvl     *AtomicLevel
+	pid     string
+}
+
+// Write implement io.Writer by sending given JSON encoded log as the message
+// of a syslog message.
+func (s *syslogOutput) Write(p []byte) (int, error) {
+	msg := s.format(bytes.TrimSpace(p), time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.redial.dial(s.dial); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.write(msg); err != nil {
+		// reconnect then retry once
+		_ = s.conn.Close()
+		s.conn = nil
+		if err = s.redial.dial(s.dial); err != nil {
+			return 0, err
+		}
+		if err = s.write(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// write write given msg to the current connection within the write timeout,
+// so the collector that stops reading does not block the caller forever.
+func (s *syslogOutput) write(msg []byte) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format return given log as RFC 5424 syslog message framed based on the
+// network and framing config.
+func (s *syslogOutput) format(p []byte, t time.Time) []byte {
+	pri := int(s.cnf.facility)*8 + toSyslogSeverity(levelOf(p))
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(pri))
+	buf.WriteString(">1 ")
+	buf.WriteString(t.Format("2006-01-02T15:04:05.000000Z07:00"))
+	buf.WriteByte(' ')
+	buf.WriteString(nilValue(s.cnf.host))
+	buf.WriteByte(' ')
+	buf.WriteString(nilValue(s.cnf.app))
+	buf.WriteByte(' ')
+	buf.WriteString(s.pid)
+	buf.WriteString(" - - ")
+	buf.Write(p)
+
+	if !s.stream() {
+		return buf.Bytes()
+	}
+	if s.cnf.framing == NewlineFraming {
+		buf.WriteByte('\n')
+		return buf.Bytes()
+	}
+	return append([]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+}
+
+// stream return true if the configured network is stream based.
+func (s *syslogOutput) stream() bool {
+	switch s.cnf.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// dial connect to the syslog collector within given timeout. No timeout if
+// zero.
+func (s *syslogOutput) dial(timeout time.Duration) error {
+	d := &net.Dialer{Timeout: timeout}
+	var err error
+	if s.cnf.network == "tls" {
+		s.conn, err = tls.DialWithDialer(d, "tcp", s.cnf.addr, s.cnf.tls)
+	} else {
+		s.conn, err = d.Dial(s.cnf.network, s.cnf.addr)
+	}
+	if err != nil {
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *syslogOutput) Writer() io.Writer         { return s }
+func (s *syslogOutput) Output() Output            { return SYSLOG }
+func (s *syslogOutput) Level() Level              { return s.lvl.Level() }
+func (s *syslogOutput) AtomicLevel() *AtomicLevel { return s.lvl }
+
+// Wait try to connect to the syslog collector within given dur.
+func (s *syslogOutput) Wait(dur time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil && dur > 0 {
+		_ = s.dial(dur)
+	}
+}
+
+// Flush close the connection to the syslog collector.
+func (s *syslogOutput) Flush(_ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+const (
+	// dialTimeout maximum time to connect to the collector on Write.
+	dialTimeout = 5 * time.Second
+	// writeTimeout maximum time to write each log to the collector.
+	writeTimeout = 5 * time.Second
+	// redialBackoff initial wait time before dialing again after a failed
+	// dial.
+	redialBackoff = 500 * time.Millisecond
+)
+
+// redialer track the failed dials, so Write does not dial on every call while
+// the collector is down. Not safe for concurrent use.
+type redialer struct {
+	failures int
+	retryAt  time.Time
+	err      error
+}
+
+// dial call given dial within dialTimeout, unless still waiting for the
+// backoff of the last failed dial, which is doubled on each failure.
+func (r *redialer) dial(dial func(time.Duration) error) error {
+	if time.Now().Before(r.retryAt) {
+		return fmt.Errorf("waiting to reconnect: %w", r.err)
+	}
+	if err := dial(dialTimeout); err != nil {
+		r.retryAt = time.Now().Add(backoffOf(redialBackoff, r.failures))
+		r.failures++
+		r.err = err
+		return err
+	}
+	r.failures, r.err = 0, nil
+	return nil
+}
+
+// syslogField return given s after replacing characters that are not allowed
+// in RFC 5424 header fields and truncated to given max length.
+func syslogField(s string, max int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > max {
+		b = b[:max]
+	}
+	return string(b)
+}
+
+// nilValue return '-' if given s is empty as in RFC 5424 NILVALUE.
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
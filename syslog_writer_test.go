@@ -0,0 +1,419 @@
+package apilog
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTLSConfig return tls config of a server with self-signed certificate
+// for 127.0.0.1 and tls config of a client that trust it.
+func testTLSConfig(t *testing.T) (srv, cl *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "apilog"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	srv = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return srv, &tls.Config{RootCAs: pool}
+}
+
+// readSyslogFrame read a single syslog message from given rd using given
+// framing.
+func readSyslogFrame(rd *bufio.Reader, f SyslogFraming) (string, error) {
+	if f == NewlineFraming {
+		line, err := rd.ReadString('\n')
+		return strings.TrimSuffix(line, "\n"), err
+	}
+	size, err := rd.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(rd, buf)
+	return string(buf), err
+}
+
+// syslogStreamServer accept connections from given ln and send every syslog
+// message to the returned channel. Close each connection after receiving
+// given max messages if positive.
+func syslogStreamServer(ln net.Listener, f SyslogFraming, max int) <-chan string {
+	ch := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for i := 0; max <= 0 || i < max; i++ {
+					msg, err := readSyslogFrame(rd, f)
+					if err != nil {
+						return
+					}
+					ch <- msg
+				}
+			}()
+		}
+	}()
+	return ch
+}
+
+// syslogPacketServer send every syslog message received by given conn to the
+// returned channel.
+func syslogPacketServer(conn net.PacketConn) <-chan string {
+	ch := make(chan string, 16)
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			ch <- string(buf[:n])
+		}
+	}()
+	return ch
+}
+
+// receive return the next message from given ch or fail after a second.
+func receive(t *testing.T, ch <-chan string) string {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return ""
+}
+
+func TestToSyslogSeverity(t *testing.T) {
+	testCases := []struct {
+		lvl    Level
+		expect int
+	}{
+		{lvl: TraceLevel, expect: 7},
+		{lvl: DebugLevel, expect: 7},
+		{lvl: InfoLevel, expect: 6},
+		{lvl: WarnLevel, expect: 4},
+		{lvl: ErrorLevel, expect: 3},
+		{lvl: PanicLevel, expect: 2},
+		{lvl: FatalLevel, expect: 1},
+		{lvl: -1, expect: 5},
+	}
+	for _, tc := range testCases {
+		t.Run("Given "+tc.lvl.String()+" level should map to severity "+strconv.Itoa(tc.expect), func(t *testing.T) {
+			assert.Equal(t, tc.expect, toSyslogSeverity(tc.lvl))
+		})
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	at := time.Date(2026, 10, 17, 7, 59, 13, 123456789, time.UTC)
+	p := []byte(`{"level":"ERROR","msg":"oops"}`)
+	header := "<131>1 2026-10-17T07:59:13.123456Z host app "
+
+	testCases := []struct {
+		name   string
+		opts   []ConfigOpt
+		expect string
+	}{
+		{
+			name:   "Given udp network should not be framed",
+			opts:   []ConfigOpt{WithSyslogNetwork("udp")},
+			expect: header + "%s - - " + string(p),
+		},
+		{
+			name:   "Given tcp network should be framed using octet counting by default",
+			opts:   []ConfigOpt{WithSyslogNetwork("tcp")},
+			expect: "%d " + header + "%s - - " + string(p),
+		},
+		{
+			name:   "Given newline framing should be terminated by newline",
+			opts:   []ConfigOpt{WithSyslogNetwork("unix"), WithSyslogFraming(NewlineFraming)},
+			expect: header + "%s - - " + string(p) + "\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append(tc.opts, WithSyslogFacility(SyslogLocal0), WithSyslogHostname("host"), WithSyslogAppName("app"))
+			s := NewSyslogWriter(InfoLevel, NewConfig(opts...)).(*syslogOutput)
+			msg := string(s.format(p, at))
+
+			expect := strings.Replace(tc.expect, "%s", s.pid, 1)
+			if strings.HasPrefix(expect, "%d") {
+				body := strings.TrimPrefix(expect, "%d ")
+				expect = strconv.Itoa(len(body)) + " " + body
+			}
+			assert.Equal(t, expect, msg)
+		})
+	}
+}
+
+func TestNewSyslogWriter(t *testing.T) {
+	t.Run("Given empty config should just use the default value instead", func(t *testing.T) {
+		wr := NewSyslogWriter(WarnLevel, nil)
+		assert.IsType(t, &syslogOutput{}, wr.Writer())
+		assert.Equal(t, SYSLOG, wr.Output())
+		assert.Equal(t, WarnLevel, wr.Level())
+
+		s := wr.(*syslogOutput)
+		assert.Equal(t, "udp", s.cnf.network)
+		assert.Equal(t, "localhost:514", s.cnf.addr)
+		assert.Equal(t, SyslogUser, s.cnf.facility)
+		assert.NotEmpty(t, s.cnf.app)
+		assert.NotContains(t, s.cnf.host, " ")
+	})
+
+	t.Run("Given unreachable collector should return error", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		wr := NewSyslogWriter(InfoLevel, NewConfig(WithSyslogNetwork("tcp"), WithSyslogAddress(addr)))
+		wr.Wait(100 * time.Millisecond)
+		_, err = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.Error(t, err)
+		// should not dial again until the backoff is passed
+		_, err = wr.Writer().Write([]byte(`{"msg":"hello"}`))
+		assert.ErrorContains(t, err, "waiting to reconnect")
+		wr.Flush(-1)
+	})
+}
+
+func TestRedialer(t *testing.T) {
+	var dialed int
+	var dialErr error
+	dial := func(timeout time.Duration) error {
+		assert.Equal(t, dialTimeout, timeout)
+		dialed++
+		return dialErr
+	}
+	var r redialer
+
+	t.Run("Given failed dial should fail fast until the backoff is passed", func(t *testing.T) {
+		dialErr = errors.New("connection refused")
+		assert.Equal(t, dialErr, r.dial(dial))
+		assert.ErrorIs(t, r.dial(dial), dialErr)
+		assert.Equal(t, 1, dialed)
+	})
+
+	t.Run("Should double the backoff on each failed dial", func(t *testing.T) {
+		r.retryAt = time.Time{}
+		start := time.Now()
+		assert.Error(t, r.dial(dial))
+		assert.Equal(t, 2, dialed)
+		assert.WithinDuration(t, start.Add(2*redialBackoff), r.retryAt, 100*time.Millisecond)
+	})
+
+	t.Run("Should reset the backoff once connected", func(t *testing.T) {
+		r.retryAt, dialErr = time.Time{}, nil
+		assert.NoError(t, r.dial(dial))
+		assert.Equal(t, 3, dialed)
+		assert.Zero(t, r.failures)
+	})
+}
+
+func TestSyslogWriter(t *testing.T) {
+	srvTLS, clTLS := testTLSConfig(t)
+
+	testCases := []struct {
+		name   string
+		listen func(t *testing.T) (addr string, ch <-chan string)
+		opts   []ConfigOpt
+	}{
+		{
+			name: "udp",
+			listen: func(t *testing.T) (string, <-chan string) {
+				conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+				return conn.LocalAddr().String(), syslogPacketServer(conn)
+			},
+		},
+		{
+			name: "tcp",
+			listen: func(t *testing.T) (string, <-chan string) {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { ln.Close() })
+				return ln.Addr().String(), syslogStreamServer(ln, OctetCounting, 0)
+			},
+		},
+		{
+			name: "tls",
+			listen: func(t *testing.T) (string, <-chan string) {
+				ln, err := tls.Listen("tcp", "127.0.0.1:0", srvTLS)
+				require.NoError(t, err)
+				t.Cleanup(func() { ln.Close() })
+				return ln.Addr().String(), syslogStreamServer(ln, NewlineFraming, 0)
+			},
+			opts: []ConfigOpt{WithSyslogTLS(clTLS), WithSyslogFraming(NewlineFraming)},
+		},
+		{
+			name: "unix",
+			listen: func(t *testing.T) (string, <-chan string) {
+				ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "syslog.sock"))
+				require.NoError(t, err)
+				t.Cleanup(func() { ln.Close() })
+				return ln.Addr().String(), syslogStreamServer(ln, OctetCounting, 0)
+			},
+		},
+		{
+			name: "unixgram",
+			listen: func(t *testing.T) (string, <-chan string) {
+				conn, err := net.ListenPacket("unixgram", filepath.Join(t.TempDir(), "syslog.sock"))
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+				return conn.LocalAddr().String(), syslogPacketServer(conn)
+			},
+		},
+	}
+	loggers := []struct {
+		name string
+		fn   func(...Writer) Logger
+	}{
+		{name: "Zap", fn: NewZapLogger},
+		{name: "Slog", fn: NewSlogLogger},
+	}
+	for _, tc := range testCases {
+		for _, lg := range loggers {
+			t.Run(lg.name+" should send each log as syslog message over "+tc.name, func(t *testing.T) {
+				addr, ch := tc.listen(t)
+				opts := append(tc.opts, WithSyslogNetwork(tc.name), WithSyslogAddress(addr),
+					WithSyslogFacility(SyslogLocal0), WithSyslogAppName("apilog"))
+				syslog := NewSyslogWriter(DebugLevel, NewConfig(opts...))
+				wr := lg.fn(syslog)
+				wr.Init(time.Second)
+				defer wr.Flush(time.Second)
+
+				wr.Wrn("warning log", String("key", "val"))
+				msg := receive(t, ch)
+				assert.True(t, strings.HasPrefix(msg, "<132>1 "), msg)
+				assert.Contains(t, msg, " apilog ")
+				assert.Contains(t, msg, `"msg":"warning log"`)
+				assert.Contains(t, msg, `"key":"val"`)
+
+				wr.Dbg("debug log")
+				assert.True(t, strings.HasPrefix(receive(t, ch), "<135>1 "))
+			})
+		}
+	}
+}
+
+// stalledListener return tcp listener that accept connections but never read
+// from them.
+func stalledListener(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		_ = ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range conns {
+			_ = c.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	return ln
+}
+
+func TestSyslogWriterStalled(t *testing.T) {
+	t.Run("Given collector that stop reading should not block Write forever", func(t *testing.T) {
+		ln := stalledListener(t)
+		wr := NewSyslogWriter(InfoLevel, NewConfig(WithSyslogNetwork("tcp"), WithSyslogAddress(ln.Addr().String())))
+		wr.(*syslogOutput).timeout = 50 * time.Millisecond
+		defer wr.Flush(0)
+
+		msg := []byte(`{"msg":"` + strings.Repeat("a", 4<<20) + `"}`)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// keep writing until the socket buffer is full
+			for i := 0; i < 16; i++ {
+				_, _ = wr.Writer().Write(msg)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Write is blocked by the stalled collector")
+		}
+	})
+}
+
+func TestSyslogWriterReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	// close the connection after each message
+	ch := syslogStreamServer(ln, OctetCounting, 1)
+
+	wr := NewSyslogWriter(InfoLevel, NewConfig(WithSyslogNetwork("tcp"), WithSyslogAddress(ln.Addr().String())))
+	defer wr.Flush(time.Second)
+
+	t.Run("Should reconnect after the connection is closed by the collector", func(t *testing.T) {
+		_, err := wr.Writer().Write([]byte(`{"msg":"first"}`))
+		require.NoError(t, err)
+		assert.Contains(t, receive(t, ch), "first")
+
+		// writes to the closed connection may not fail immediately
+		assert.Eventually(t, func() bool {
+			_, _ = wr.Writer().Write([]byte(`{"msg":"second"}`))
+			select {
+			case msg := <-ch:
+				return strings.Contains(msg, "second")
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, 20*time.Millisecond)
+	})
+}
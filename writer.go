@@ -1,6 +1,7 @@
 package apilog
 
 import (
+	"encoding/json"
 	"io"
 	"time"
 )
@@ -24,7 +25,115 @@ type Writer interface {
 type Output int8
 
 const (
-	CONSOLE  Output = iota // CONSOLE target log output to console/terminal
-	NEWRELIC               // NEWRELIC target log output directly to new relic via newrelic client sdk
-	FILE                   // FILE target log output to local file
+	CONSOLE       Output = iota // CONSOLE target log output to console/terminal
+	NEWRELIC                    // NEWRELIC target log output directly to new relic via newrelic client sdk
+	FILE                        // FILE target log output to local file
+	SYSLOG                      // SYSLOG target log output to syslog collector using RFC 5424
+	HTTP                        // HTTP target log output to HTTP endpoint that accept JSON array of logs
+	LOKI                        // LOKI target log output to grafana loki push API
+	OTLP                        // OTLP target log output to OpenTelemetry collector using OTLP/HTTP
+	ELASTICSEARCH               // ELASTICSEARCH target log output to elasticsearch or opensearch bulk API
+	FLUENT                      // FLUENT target log output to fluentd or fluent bit using the forward protocol
+	GELF                        // GELF target log output to graylog using GELF over udp or tcp
+	SPLUNK                      // SPLUNK target log output to splunk HTTP Event Collector
+	DATADOG                     // DATADOG target log output to datadog logs intake API
+	KAFKA                       // KAFKA target log output to kafka using the given KafkaProducer
+	JOURNALD                    // JOURNALD target log output to systemd journald using the native protocol
+	NETWORK                     // NETWORK target log output to tcp, udp or unix socket as newline delimited JSON
 )
+
+// LevelAdjuster optional interface that may be implemented by Writer whose
+// Level can be changed at runtime.
+type LevelAdjuster interface {
+	// AtomicLevel return the AtomicLevel backing the Writer's Level. May
+	// return nil if the Level can not be changed.
+	AtomicLevel() *AtomicLevel
+}
+
+// atomicLevelOf return the AtomicLevel of given w if any, otherwise nil.
+func atomicLevelOf(w Writer) *AtomicLevel {
+	if la, ok := w.(LevelAdjuster); ok {
+		return la.AtomicLevel()
+	}
+	return nil
+}
+
+// Reopener optional interface that may be implemented by Writer that write
+// logs to local file, so the file can be rotated by external tools e.g.
+// logrotate.
+type Reopener interface {
+	// Rotate close the current file, rename it as a backup then open a new
+	// file using the original name.
+	Rotate() error
+	// Reopen close the current file, so the next write open the file using
+	// the original name again e.g. after the file is moved by logrotate.
+	Reopen() error
+}
+
+// reopenerOf return the Reopener of given w, or of any Writer wrapped by w if
+// any, otherwise nil.
+func reopenerOf(w Writer) Reopener {
+	for w != nil {
+		if r, ok := w.(Reopener); ok {
+			return r
+		}
+		u, ok := w.(interface{ Unwrap() Writer })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+	return nil
+}
+
+// ConnState state of the connection of Writer that send logs over network.
+type ConnState int8
+
+const (
+	Disconnected ConnState = iota // Disconnected not connected yet or the connection is lost
+	Connected                     // Connected the connection is established
+)
+
+// String returns the lower-case representation of the connection state.
+func (c ConnState) String() string {
+	if c == Connected {
+		return "connected"
+	}
+	return "disconnected"
+}
+
+// Connector optional interface that may be implemented by Writer that keep a
+// connection open in the background, so its state can be used for health
+// check.
+type Connector interface {
+	// ConnState return the current state of the connection.
+	ConnState() ConnState
+}
+
+// ConnStateOf return the connection state of given w, or of any Writer
+// wrapped by w e.g. by NewAsyncWriter. Return false if none of them is a
+// Connector.
+func ConnStateOf(w Writer) (ConnState, bool) {
+	for w != nil {
+		if c, ok := w.(Connector); ok {
+			return c.ConnState(), true
+		}
+		u, ok := w.(interface{ Unwrap() Writer })
+		if !ok {
+			return Disconnected, false
+		}
+		w = u.Unwrap()
+	}
+	return Disconnected, false
+}
+
+// levelOf return the Level of given JSON encoded log, or -1 if not found.
+func levelOf(p []byte) Level {
+	var l struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(p, &l); err != nil {
+		return -1
+	}
+	return ParseLevel(l.Level)
+}
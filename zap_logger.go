@@ -1,6 +1,7 @@
 package apilog
 
 import (
+	"fmt"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,8 +16,9 @@ func NewZapLogger(wr ...Writer) Logger {
 }
 
 type zapLogger struct {
-	log *zap.Logger
-	wr  []Writer
+	log  *zap.Logger
+	wr   []Writer
+	opts loggerOpts
 }
 
 func (z *zapLogger) clone() *zapLogger {
@@ -29,26 +31,39 @@ func (z *zapLogger) Init(dur time.Duration) {
 	// setup common zap json encoder
 	jsonEnc := zap.NewProductionEncoderConfig()
 	jsonEnc.EncodeTime = zapcore.RFC3339TimeEncoder
-	jsonEnc.EncodeLevel = zapcore.CapitalLevelEncoder
+	jsonEnc.EncodeLevel = zapCapitalLevelEncoder
 	jsonEnc.TimeKey = "time"
+	jsonEnc.EncodeDuration = zapcore.StringDurationEncoder
 
 	for _, w := range z.wr {
 		switch w.Output() {
 		case CONSOLE:
 			encCnf := zap.NewDevelopmentConfig().EncoderConfig
-			encCnf.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			encCnf.EncodeLevel = zapCapitalColorLevelEncoder
 			enc := zapcore.NewConsoleEncoder(encCnf)
-			core := zapcore.NewCore(enc, zapcore.AddSync(w.Writer()), toZapLevel(w.Level()))
-			cores = append(cores, core)
+			core := zapcore.NewCore(enc, zapcore.AddSync(w.Writer()), toZapLevelEnabler(w))
+			cores = append(cores, toZapSampler(core, w))
 
-		case FILE, NEWRELIC:
+		case LOKI:
+			// loki orders the entries of a stream by their timestamp, so
+			// keep the nanoseconds to not reorder logs within a second
+			lokiEnc := jsonEnc
+			lokiEnc.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+			enc := zapcore.NewJSONEncoder(lokiEnc)
+			core := zapcore.NewCore(enc, zapcore.AddSync(w.Writer()), toZapLevelEnabler(w))
+			cores = append(cores, toZapSampler(core, w))
+
+		case FILE, NEWRELIC, SYSLOG, HTTP, OTLP, ELASTICSEARCH, FLUENT, GELF, SPLUNK, DATADOG, KAFKA, JOURNALD, NETWORK:
 			enc := zapcore.NewJSONEncoder(jsonEnc)
-			core := zapcore.NewCore(enc, zapcore.AddSync(w.Writer()), toZapLevel(w.Level()))
-			cores = append(cores, core)
+			core := zapcore.NewCore(enc, zapcore.AddSync(w.Writer()), toZapLevelEnabler(w))
+			cores = append(cores, toZapSampler(core, w))
 		}
 		w.Wait(dur)
 	}
-	z.log = zap.New(zapcore.NewTee(cores...))
+	// skip the zapLogger methods, so the reported caller is the caller of
+	// the Logger instead
+	opts := append([]zap.Option{zap.AddCallerSkip(1)}, toZapOptions(loggerOpts{}, z.opts)...)
+	z.log = zap.New(zapcore.NewTee(cores...), append(opts, z.terminalHooks()...)...)
 }
 
 func (z *zapLogger) Flush(dur time.Duration) {
@@ -58,6 +73,35 @@ func (z *zapLogger) Flush(dur time.Duration) {
 	_ = z.log.Sync()
 }
 
+func (z *zapLogger) Enabled(lvl Level) bool {
+	if z.log == nil {
+		return false
+	}
+	return z.log.Core().Enabled(toZapLevel(lvl))
+}
+
+func (z *zapLogger) WithOptions(opts ...LoggerOpt) Logger {
+	if len(opts) == 0 {
+		return z
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// clone it, so on every WithOptions method call does not affect the parent logger
+	clone := z.clone()
+	clone.opts = z.opts.apply(opts)
+	// already initialized, so apply the changes to the zap logger as well
+	if clone.log != nil {
+		opts := append(toZapOptions(z.opts, clone.opts), clone.terminalHooks()...)
+		clone.log = clone.log.WithOptions(opts...)
+	}
+
+	// then reassign to singleton
+	singletonLogger = clone
+
+	return clone
+}
+
 func (z *zapLogger) With(pr ...Log) Logger {
 	if len(pr) == 0 {
 		return z
@@ -67,7 +111,7 @@ func (z *zapLogger) With(pr ...Log) Logger {
 
 	// clone it, so on every With method call does not affect the parent logger
 	clone := z.clone()
-	clone.log = clone.log.With(toZapFields(pr)...)
+	clone.log = clone.log.With(z.fields(pr)...)
 
 	// then reassign to singleton
 	singletonLogger = clone
@@ -94,7 +138,7 @@ func (z *zapLogger) Group(key string, pr ...Log) Logger {
 	//
 	//  notice that 'ctx' is embedded as 'repo_layer' field when using Namespace, but
 	//   it's properly wrapped as intended when using Any
-	clone.log = clone.log.With(zap.Any(key, toZapFields(pr)))
+	clone.log = clone.log.With(zap.Any(key, z.fields(pr)))
 
 	// then reassign to singleton
 	singletonLogger = clone
@@ -102,41 +146,113 @@ func (z *zapLogger) Group(key string, pr ...Log) Logger {
 	return clone
 }
 
+func (z *zapLogger) Trc(msg string, pr ...Log) {
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapTraceLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
+	}
+}
+
 func (z *zapLogger) Dbg(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		z.log.Debug(msg, toZapFields(pr)...)
-		return
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
 	}
-	z.log.Debug(msg)
 }
 
 func (z *zapLogger) Inf(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		z.log.Info(msg, toZapFields(pr)...)
-		return
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
 	}
-	z.log.Info(msg)
 }
 
 func (z *zapLogger) Wrn(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		z.log.Warn(msg, toZapFields(pr)...)
-		return
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
 	}
-	z.log.Warn(msg)
 }
 
 func (z *zapLogger) Err(msg string, pr ...Log) {
-	if len(pr) > 0 {
-		z.log.Error(msg, toZapFields(pr)...)
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
+	}
+}
+
+func (z *zapLogger) Pnc(msg string, pr ...Log) {
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
+	}
+}
+
+func (z *zapLogger) Ftl(msg string, pr ...Log) {
+	// only transform the Log(s) if at least one core will write it
+	if ce := z.log.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(z.fields(pr)...)
+	}
+}
+
+// fields transform given Log(s) to zap fields after masking the sensitive
+// ones.
+func (z *zapLogger) fields(pr []Log) []zapcore.Field {
+	return toZapFields(z.opts.redactor.redact(pr))
+}
+
+// terminalHooks return zap options that replace the default panic and fatal
+// hooks, so every Writer is flushed before panics or exits.
+func (z *zapLogger) terminalHooks() []zap.Option {
+	return []zap.Option{
+		zap.WithPanicHook(zapTerminalHook{z: z, panic: true}),
+		zap.WithFatalHook(zapTerminalHook{z: z}),
+	}
+}
+
+// zapTerminalHook flush every Writer of the zapLogger then panics or exits.
+type zapTerminalHook struct {
+	z     *zapLogger
+	panic bool
+}
+
+func (h zapTerminalHook) OnWrite(ce *zapcore.CheckedEntry, _ []zapcore.Field) {
+	h.z.Flush(h.z.opts.exitTimeout())
+	if h.panic {
+		panic(ce.Message)
+	}
+	exit(1)
+}
+
+// zapTraceLevel custom zap level that is not supported by zap out of the box.
+const zapTraceLevel = zapcore.DebugLevel - 1
+
+// zapCapitalLevelEncoder same as zapcore.CapitalLevelEncoder but also support
+// zapTraceLevel.
+func zapCapitalLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == zapTraceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalLevelEncoder(l, enc)
+}
+
+// zapCapitalColorLevelEncoder same as zapcore.CapitalColorLevelEncoder but
+// also support zapTraceLevel.
+func zapCapitalColorLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == zapTraceLevel {
+		// cyan
+		enc.AppendString("\x1b[36mTRACE\x1b[0m")
 		return
 	}
-	z.log.Error(msg)
+	zapcore.CapitalColorLevelEncoder(l, enc)
 }
 
 // toZapLevel transform log Level to zap level.
 func toZapLevel(lvl Level) zapcore.Level {
 	switch lvl {
+	case TraceLevel:
+		return zapTraceLevel
 	case DebugLevel:
 		return zapcore.DebugLevel
 	case InfoLevel:
@@ -145,14 +261,58 @@ func toZapLevel(lvl Level) zapcore.Level {
 		return zapcore.WarnLevel
 	case ErrorLevel:
 		return zapcore.ErrorLevel
+	case PanicLevel:
+		return zapcore.PanicLevel
+	case FatalLevel:
+		return zapcore.FatalLevel
 	}
 	return zapcore.InvalidLevel
 }
 
+// toZapLevelEnabler return the zap AtomicLevel of given w if its Level can be
+// changed at runtime, otherwise just use the fixed Level.
+func toZapLevelEnabler(w Writer) zapcore.LevelEnabler {
+	if al := atomicLevelOf(w); al != nil {
+		return al.zap
+	}
+	return toZapLevel(w.Level())
+}
+
+// toZapOptions transform the changes from prev to next loggerOpts to zap
+// options. Required since zap caller skip is cumulative.
+func toZapOptions(prev, next loggerOpts) []zap.Option {
+	opts := []zap.Option{
+		zap.WithCaller(next.caller),
+		zap.AddCallerSkip(next.callerSkip - prev.callerSkip),
+	}
+	switch {
+	case next.stack:
+		opts = append(opts, zap.AddStacktrace(toZapLevel(next.stackLevel)))
+	case prev.stack:
+		// never attach stack trace
+		opts = append(opts, zap.AddStacktrace(zapcore.InvalidLevel))
+	}
+	return opts
+}
+
+// toZapSampler wrap given core with zap sampler if given w is SampledWriter,
+// otherwise return as is.
+func toZapSampler(core zapcore.Core, w Writer) zapcore.Core {
+	sw := samplingOf(w)
+	if sw == nil {
+		return core
+	}
+	hook := zapcore.SamplerHook(func(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+		sw.record(dec&zapcore.LogDropped > 0)
+	})
+	return zapcore.NewSamplerWithOptions(core, sw.tick, sw.first, sw.thereafter, hook)
+}
+
 // toZapFields transform Log to zap field.
 func toZapFields(pr []Log) []zapcore.Field {
 	var fields []zapcore.Field
 	for _, p := range pr {
+		p = p.resolve()
 		switch p.typ {
 		case StringType:
 			fields = append(fields, zap.String(p.key, p.str))
@@ -166,7 +326,58 @@ func toZapFields(pr []Log) []zapcore.Field {
 			fields = append(fields, zap.Any(p.key, p.any))
 		case ErrorType:
 			fields = append(fields, zap.NamedError(p.key, p.err))
+		case Int64Type:
+			fields = append(fields, zap.Int64(p.key, p.i64))
+		case Uint64Type:
+			fields = append(fields, zap.Uint64(p.key, p.u64))
+		case DurationType:
+			fields = append(fields, zap.Duration(p.key, time.Duration(p.i64)))
+		case TimeType:
+			fields = append(fields, zap.String(p.key, p.any.(time.Time).Format(time.RFC3339Nano)))
+		case StringsType:
+			fields = append(fields, zap.Strings(p.key, p.any.([]string)))
+		case IntsType:
+			fields = append(fields, zap.Ints(p.key, p.any.([]int)))
+		case BytesType:
+			fields = append(fields, zap.ByteString(p.key, p.any.([]byte)))
+		case StringerType:
+			fields = append(fields, zap.Stringer(p.key, p.any.(fmt.Stringer)))
+		case ObjectType:
+			fields = append(fields, zap.Object(p.key, zapObject{v: p.any.(ObjectMarshaler)}))
+		case SecretType:
+			fields = append(fields, zap.String(p.key, MaskFull(p.str)))
 		}
 	}
 	return fields
 }
+
+// zapObject adapt ObjectMarshaler to zapcore.ObjectMarshaler.
+type zapObject struct {
+	v ObjectMarshaler
+}
+
+func (z zapObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return z.v.MarshalLogObject(zapObjectEncoder{enc: enc})
+}
+
+// zapObjectEncoder adapt zapcore.ObjectEncoder to ObjectEncoder.
+type zapObjectEncoder struct {
+	enc zapcore.ObjectEncoder
+}
+
+func (z zapObjectEncoder) AddString(k, v string)        { z.enc.AddString(k, v) }
+func (z zapObjectEncoder) AddNum(k string, v int)       { z.enc.AddInt(k, v) }
+func (z zapObjectEncoder) AddInt64(k string, v int64)   { z.enc.AddInt64(k, v) }
+func (z zapObjectEncoder) AddUint64(k string, v uint64) { z.enc.AddUint64(k, v) }
+func (z zapObjectEncoder) AddFloat(k string, v float64) { z.enc.AddFloat64(k, v) }
+func (z zapObjectEncoder) AddBool(k string, v bool)     { z.enc.AddBool(k, v) }
+func (z zapObjectEncoder) AddDuration(k string, v time.Duration) {
+	z.enc.AddDuration(k, v)
+}
+func (z zapObjectEncoder) AddTime(k string, v time.Time) {
+	z.enc.AddString(k, v.Format(time.RFC3339Nano))
+}
+func (z zapObjectEncoder) AddObject(k string, v ObjectMarshaler) error {
+	return z.enc.AddObject(k, zapObject{v: v})
+}
+func (z zapObjectEncoder) AddAny(k string, v any) error { return z.enc.AddReflected(k, v) }
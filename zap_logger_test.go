@@ -0,0 +1,152 @@
+package apilog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestToZapLevel(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sample Level
+		expect zapcore.Level
+	}{
+		{
+			name:   "Trace level",
+			sample: TraceLevel,
+			expect: zapTraceLevel,
+		},
+		{
+			name:   "Debug level",
+			sample: DebugLevel,
+			expect: zapcore.DebugLevel,
+		},
+		{
+			name:   "Info level",
+			sample: InfoLevel,
+			expect: zapcore.InfoLevel,
+		},
+		{
+			name:   "Warn level",
+			sample: WarnLevel,
+			expect: zapcore.WarnLevel,
+		},
+		{
+			name:   "Error level",
+			sample: ErrorLevel,
+			expect: zapcore.ErrorLevel,
+		},
+		{
+			name:   "Panic level",
+			sample: PanicLevel,
+			expect: zapcore.PanicLevel,
+		},
+		{
+			name:   "Fatal level",
+			sample: FatalLevel,
+			expect: zapcore.FatalLevel,
+		},
+		{
+			name:   "Unrecognized level",
+			sample: -1,
+			expect: zapcore.InvalidLevel,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, toZapLevel(tc.sample))
+		})
+	}
+}
+
+// stringArrayEncoder zapcore.PrimitiveArrayEncoder that only record the
+// appended strings.
+type stringArrayEncoder struct {
+	zapcore.PrimitiveArrayEncoder
+	elems []string
+}
+
+func (s *stringArrayEncoder) AppendString(v string) { s.elems = append(s.elems, v) }
+
+func TestZapLevelEncoder(t *testing.T) {
+	enc := new(stringArrayEncoder)
+	zapCapitalLevelEncoder(zapTraceLevel, enc)
+	zapCapitalLevelEncoder(zapcore.DebugLevel, enc)
+	zapCapitalColorLevelEncoder(zapTraceLevel, enc)
+	zapCapitalColorLevelEncoder(zapcore.DebugLevel, enc)
+
+	assert.Equal(t, []string{"TRACE", "DEBUG", "\x1b[36mTRACE\x1b[0m", "\x1b[35mDEBUG\x1b[0m"}, enc.elems)
+}
+
+func TestNewZapLogger(t *testing.T) {
+	t.Run("Console Writer type", func(t *testing.T) {
+		writer, obs := NewObserverWriter(TraceLevel, CONSOLE)
+		wr := NewZapLogger(writer)
+		wr.Init(time.Microsecond)
+
+		wr.Trc("trace log")
+		wr.Dbg("debug log")
+
+		// just assert the logs len, since the zap console encoder is not json string
+		require.Equal(t, 2, obs.Len())
+		wr.Flush(time.Microsecond)
+	})
+
+	t.Run("File or Newrelic Writer type", func(t *testing.T) {
+		writer, obs := NewObserverWriter(TraceLevel, FILE)
+		wr := NewZapLogger(writer)
+		wr.Init(time.Microsecond)
+
+		wr.Trc("trace log", String("hello", "world"))
+		wr.Trc("another trace log")
+		wr.Dbg("debug log")
+
+		require.Equal(t, 3, obs.Len())
+		trc := obs.All()[0]
+		assert.True(t, trc.EqualLevel(TraceLevel))
+		assert.True(t, trc.EqualMsg("trace log"))
+		assert.Equal(t, "world", trc.Get("hello"))
+		assert.True(t, obs.All()[2].EqualLevel(DebugLevel))
+		wr.Flush(time.Microsecond)
+	})
+
+	t.Run("Given File Writer type should encode the time in RFC3339", func(t *testing.T) {
+		writer, obs := NewObserverWriter(TraceLevel, FILE)
+		wr := NewZapLogger(writer)
+		wr.Init(time.Microsecond)
+
+		wr.Inf("info log")
+		require.Equal(t, 1, obs.Len())
+		ts, _ := obs.All()[0].Get("time").(string)
+		_, err := time.Parse(time.RFC3339, ts)
+		require.NoError(t, err)
+		assert.NotContains(t, ts, ".")
+	})
+
+	t.Run("Given Loki Writer type should encode the time in RFC3339 with nanoseconds", func(t *testing.T) {
+		writer, obs := NewObserverWriter(TraceLevel, LOKI)
+		wr := NewZapLogger(writer)
+		wr.Init(time.Microsecond)
+
+		wr.Inf("info log")
+		require.Equal(t, 1, obs.Len())
+		ts, _ := obs.All()[0].Get("time").(string)
+		_, err := time.Parse(time.RFC3339Nano, ts)
+		require.NoError(t, err)
+		assert.Contains(t, ts, ".")
+	})
+
+	t.Run("Trace log should not be written in Debug level", func(t *testing.T) {
+		writer, obs := NewObserverWriter(DebugLevel, FILE)
+		wr := NewZapLogger(writer)
+		wr.Init(time.Microsecond)
+
+		wr.Trc("trace log")
+		assert.Equal(t, 0, obs.Len())
+	})
+}